@@ -0,0 +1,677 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	defaultProbeInterval = 30 * time.Second
+	defaultHTTPTimeout   = 10 * time.Second
+	defaultEventWindow   = 10 * time.Minute
+)
+
+const (
+	// allPrometheusSources is the prometheus.source of probes that run against every Prometheus source.
+	allPrometheusSources = "*"
+	// sourcePlaceholder is replaced with the name of the Prometheus source in the sub-component of a probe.
+	sourcePlaceholder = "{source}"
+)
+
+// MonitorConfig is the configuration of the component monitor.
+type MonitorConfig struct {
+	// PrometheusSources are named Prometheus APIs that probes can query instead of the one configured with flags.
+	PrometheusSources []PrometheusSource `yaml:"prometheus_sources"`
+	Probes            []ProbeConfig      `yaml:"probes"`
+}
+
+// PrometheusSource is a named Prometheus compatible API, reached at its URL or by discovering its route with a
+// context of the kubeconfig.
+type PrometheusSource struct {
+	Name string `yaml:"name"`
+	// KubeconfigContext is the context of the kubeconfig that the route is discovered with.
+	KubeconfigContext    string `yaml:"kubeconfig_context"`
+	PrometheusConnection `yaml:",inline"`
+}
+
+func (s *PrometheusSource) setDefaults() {
+	if s.URL == "" && s.Route == "" {
+		s.Route = defaultPrometheusRoute
+	}
+}
+
+func (s *PrometheusSource) validate() error {
+	if s.Name == allPrometheusSources {
+		return fmt.Errorf("name %q is reserved for probes that run against every source", s.Name)
+	}
+	if (s.URL == "") == (s.KubeconfigContext == "") {
+		return errors.New("exactly one of url and kubeconfig_context is required")
+	}
+	return s.PrometheusConnection.Validate()
+}
+
+// ProbeType selects how a probe checks the health of a sub-component.
+type ProbeType string
+
+const (
+	ProbeTypePrometheus ProbeType = "prometheus"
+	ProbeTypeHTTP       ProbeType = "http"
+	ProbeTypeKubernetes ProbeType = "kubernetes"
+)
+
+// ProbeConfig defines a single health check of a sub-component.
+type ProbeConfig struct {
+	Name         string    `yaml:"name"`
+	Component    string    `yaml:"component"`
+	SubComponent string    `yaml:"sub_component"`
+	Type         ProbeType `yaml:"type"`
+	// Interval is how often the probe runs, and defaults to 30s.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout bounds each run of the probe, and defaults to the interval.
+	Timeout time.Duration `yaml:"timeout"`
+	// Severity is the severity of the outage opened when the probe fails, and defaults to Down.
+	Severity types.Severity `yaml:"severity"`
+	// FailureThreshold is how many consecutive failures open an outage, and defaults to 1.
+	FailureThreshold int `yaml:"failure_threshold"`
+	// SuccessThreshold is how many consecutive successes resolve an outage, and defaults to 1.
+	SuccessThreshold int `yaml:"success_threshold"`
+	// MinOutageDuration is how long an outage stays open at least, however soon the probe recovers.
+	MinOutageDuration time.Duration `yaml:"min_outage_duration"`
+	// Flapping turns a probe that keeps changing between healthy and unhealthy into a single Suspected outage.
+	Flapping *FlapDetection `yaml:"flapping,omitempty"`
+
+	Prometheus *PrometheusProbeConfig `yaml:"prometheus,omitempty"`
+	HTTP       *HTTPProbeConfig       `yaml:"http,omitempty"`
+	Kubernetes *KubernetesProbeConfig `yaml:"kubernetes,omitempty"`
+}
+
+// FlapDetection considers a probe to be flapping when its result changed between healthy and unhealthy at least
+// Transitions times within Window.
+type FlapDetection struct {
+	Transitions int           `yaml:"transitions"`
+	Window      time.Duration `yaml:"window"`
+}
+
+// PrometheusProbeConfig defines a probe that evaluates a PromQL query.
+type PrometheusProbeConfig struct {
+	Query string `yaml:"query"`
+	// UnhealthyWhen is shorthand for a single threshold with the severity of the probe. It defaults to the query
+	// returning any sample, which suits queries such as absent(up{job="deck"} == 1).
+	UnhealthyWhen Condition `yaml:"unhealthy_when"`
+	// Thresholds map conditions on the query result to severities. When several match, the most severe wins.
+	Thresholds []Threshold `yaml:"thresholds"`
+	// For is how long a threshold must match before the probe fails, evaluated with a range query. The query
+	// is evaluated once when zero.
+	For time.Duration `yaml:"for"`
+	// Step is the resolution of the range query used with For, and defaults to the interval of the probe.
+	Step time.Duration `yaml:"step"`
+	// Source is the name of the Prometheus source to query, or * to run the probe against every source. The
+	// Prometheus configured with flags is queried when empty.
+	Source string `yaml:"source"`
+}
+
+// thresholds returns the configured thresholds, or the single threshold that UnhealthyWhen is shorthand for.
+func (p *PrometheusProbeConfig) thresholds(severity types.Severity) []Threshold {
+	if len(p.Thresholds) > 0 {
+		return p.Thresholds
+	}
+	return []Threshold{{Condition: p.UnhealthyWhen, Severity: severity}}
+}
+
+// Threshold is a condition on a query result and the severity of the outage when it matches.
+type Threshold struct {
+	Condition `yaml:",inline"`
+	// Severity defaults to the severity of the probe.
+	Severity types.Severity `yaml:"severity"`
+}
+
+func (p *PrometheusProbeConfig) validate() []error {
+	var errs []error
+	if p.Query == "" {
+		errs = append(errs, errors.New("prometheus.query is required"))
+	}
+	if len(p.Thresholds) == 0 {
+		if err := p.UnhealthyWhen.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("prometheus.unhealthy_when: %w", err))
+		}
+	} else if p.UnhealthyWhen.Operator != "" {
+		errs = append(errs, errors.New("prometheus.unhealthy_when and prometheus.thresholds cannot be combined"))
+	}
+	for i, threshold := range p.Thresholds {
+		if err := threshold.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("prometheus.thresholds[%d]: %w", i, err))
+		}
+		if !types.IsValidSeverity(string(threshold.Severity)) {
+			errs = append(errs, fmt.Errorf("prometheus.thresholds[%d]: invalid severity %q: must be one of Down, Degraded, Suspected", i, threshold.Severity))
+		}
+	}
+	if p.For < 0 {
+		errs = append(errs, fmt.Errorf("invalid prometheus.for %s: must not be negative", p.For))
+	}
+	if p.For > 0 && (p.Step <= 0 || p.Step > p.For) {
+		errs = append(errs, fmt.Errorf("invalid prometheus.step %s: must be positive and no longer than prometheus.for", p.Step))
+	}
+	return errs
+}
+
+// HTTPProbeConfig defines a probe that requests an HTTP or HTTPS endpoint. The probe fails when the request cannot
+// be made or the response does not satisfy the assertions, and finds the sub-component Degraded when the response
+// is correct but slower than DegradedLatency.
+type HTTPProbeConfig struct {
+	URL string `yaml:"url"`
+	// Method defaults to GET.
+	Method  string            `yaml:"method"`
+	Headers map[string]string `yaml:"headers"`
+	// ExpectedStatusCodes defaults to 200.
+	ExpectedStatusCodes []int `yaml:"expected_status_codes"`
+	// BodyRegex is a regular expression that the response body must match.
+	BodyRegex string `yaml:"body_regex"`
+	// JSONPath is an assertion on the response body parsed as JSON.
+	JSONPath *JSONPathAssertion `yaml:"json_path,omitempty"`
+	// DegradedLatency is the response time above which the sub-component is Degraded, or has the severity of the
+	// probe when that is Suspected. It is disabled when zero.
+	DegradedLatency time.Duration `yaml:"degraded_latency"`
+	// Timeout bounds the whole request and must be shorter than the timeout of the probe. It defaults to 10s, or to
+	// half the timeout of the probe when that is shorter.
+	Timeout time.Duration `yaml:"timeout"`
+	TLS     HTTPTLSConfig `yaml:"tls"`
+}
+
+// JSONPathAssertion checks a value of a JSON response body, with paths written as in kubectl, such as {.status}.
+type JSONPathAssertion struct {
+	Path string `yaml:"path"`
+	// Value is the value that the path must evaluate to. When empty, the path only has to exist.
+	Value string `yaml:"value"`
+}
+
+// HTTPTLSConfig configures how the certificates of HTTPS endpoints are verified.
+type HTTPTLSConfig struct {
+	// CAFile is a PEM bundle of certificate authorities to trust instead of the system ones.
+	CAFile     string `yaml:"ca_file"`
+	ServerName string `yaml:"server_name"`
+	// InsecureSkipVerify disables certificate verification entirely.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+func (h *HTTPProbeConfig) validate() []error {
+	var errs []error
+	if h.URL == "" {
+		errs = append(errs, errors.New("http.url is required"))
+	} else if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("invalid http.url %q: must be an absolute http or https URL", h.URL))
+	}
+	if !validHTTPMethod.MatchString(h.Method) {
+		errs = append(errs, fmt.Errorf("invalid http.method %q", h.Method))
+	}
+	for _, code := range h.ExpectedStatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, fmt.Errorf("invalid http.expected_status_codes entry %d: must be between 100 and 599", code))
+		}
+	}
+	if _, err := regexp.Compile(h.BodyRegex); err != nil {
+		errs = append(errs, fmt.Errorf("invalid http.body_regex: %w", err))
+	}
+	if h.JSONPath != nil {
+		if h.JSONPath.Path == "" {
+			errs = append(errs, errors.New("http.json_path.path is required"))
+		} else if err := jsonpath.New("").Parse(h.JSONPath.Path); err != nil {
+			errs = append(errs, fmt.Errorf("invalid http.json_path.path: %w", err))
+		}
+	}
+	if h.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid http.timeout %s: must not be negative", h.Timeout))
+	}
+	if h.DegradedLatency < 0 || (h.DegradedLatency > 0 && h.DegradedLatency >= h.Timeout) {
+		errs = append(errs, fmt.Errorf("invalid http.degraded_latency %s: must be shorter than the timeout of %s", h.DegradedLatency, h.Timeout))
+	}
+	return errs
+}
+
+// validHTTPMethod matches HTTP method names, which are tokens.
+var validHTTPMethod = regexp.MustCompile(`^[A-Z]+$`)
+
+// KubernetesProbeConfig defines a probe that checks the health of workloads in a namespace. The probe fails when
+// any of its checks does.
+type KubernetesProbeConfig struct {
+	Namespace string `yaml:"namespace"`
+	// Workloads must have enough available replicas.
+	Workloads []WorkloadCheck `yaml:"workloads"`
+	// CrashLoopingPods fails when any pod matching it has a container in CrashLoopBackOff.
+	CrashLoopingPods *PodSelector `yaml:"crash_looping_pods,omitempty"`
+	// Events fails when Warning events with any of the reasons were recorded in the namespace recently.
+	Events *EventCheck `yaml:"events,omitempty"`
+}
+
+// WorkloadKind is the kind of a workload checked by a Kubernetes probe.
+type WorkloadKind string
+
+const (
+	WorkloadKindDeployment  WorkloadKind = "Deployment"
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+)
+
+// WorkloadCheck requires a Deployment or StatefulSet to have a minimum number of available replicas.
+type WorkloadCheck struct {
+	Kind WorkloadKind `yaml:"kind"`
+	Name string       `yaml:"name"`
+	// MinAvailable defaults to the desired number of replicas of the workload.
+	MinAvailable *int32 `yaml:"min_available,omitempty"`
+}
+
+// PodSelector selects pods by label, such as app=tide.
+type PodSelector struct {
+	Selector string `yaml:"selector"`
+}
+
+// EventCheck looks for Warning events with particular reasons.
+type EventCheck struct {
+	Reasons []string `yaml:"reasons"`
+	// InvolvedObject restricts the check to events about the object with this name.
+	InvolvedObject string `yaml:"involved_object"`
+	// Window is how far back events are considered, and defaults to 10m.
+	Window time.Duration `yaml:"window"`
+}
+
+func (k *KubernetesProbeConfig) validate() []error {
+	var errs []error
+	if k.Namespace == "" {
+		errs = append(errs, errors.New("kubernetes.namespace is required"))
+	}
+	if len(k.Workloads) == 0 && k.CrashLoopingPods == nil && k.Events == nil {
+		errs = append(errs, errors.New("kubernetes probes require at least one of workloads, crash_looping_pods and events"))
+	}
+	for i, workload := range k.Workloads {
+		if workload.Kind != WorkloadKindDeployment && workload.Kind != WorkloadKindStatefulSet {
+			errs = append(errs, fmt.Errorf("kubernetes.workloads[%d]: invalid kind %q: must be one of Deployment, StatefulSet", i, workload.Kind))
+		}
+		if workload.Name == "" {
+			errs = append(errs, fmt.Errorf("kubernetes.workloads[%d]: name is required", i))
+		}
+		if workload.MinAvailable != nil && *workload.MinAvailable < 0 {
+			errs = append(errs, fmt.Errorf("kubernetes.workloads[%d]: min_available must not be negative", i))
+		}
+	}
+	if k.CrashLoopingPods != nil {
+		if k.CrashLoopingPods.Selector == "" {
+			errs = append(errs, errors.New("kubernetes.crash_looping_pods.selector is required"))
+		} else if _, err := labels.Parse(k.CrashLoopingPods.Selector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid kubernetes.crash_looping_pods.selector: %w", err))
+		}
+	}
+	if k.Events != nil {
+		if len(k.Events.Reasons) == 0 {
+			errs = append(errs, errors.New("kubernetes.events.reasons is required"))
+		}
+		if k.Events.Window < 0 {
+			errs = append(errs, fmt.Errorf("invalid kubernetes.events.window %s: must not be negative", k.Events.Window))
+		}
+	}
+	return errs
+}
+
+// ConditionOperator compares the samples returned by a query with a value.
+type ConditionOperator string
+
+const (
+	// OperatorAny matches when the query returns any sample, whatever its value.
+	OperatorAny ConditionOperator = "any"
+	// OperatorNone matches when the query returns no samples.
+	OperatorNone           ConditionOperator = "none"
+	OperatorGreater        ConditionOperator = ">"
+	OperatorGreaterOrEqual ConditionOperator = ">="
+	OperatorLess           ConditionOperator = "<"
+	OperatorLessOrEqual    ConditionOperator = "<="
+	OperatorEqual          ConditionOperator = "=="
+	OperatorNotEqual       ConditionOperator = "!="
+)
+
+// Condition is a check on the samples returned by a query. Comparisons match when any sample satisfies them.
+type Condition struct {
+	Operator ConditionOperator `yaml:"operator"`
+	Value    float64           `yaml:"value"`
+}
+
+func (c Condition) validate() error {
+	switch c.Operator {
+	case OperatorAny, OperatorNone, OperatorGreater, OperatorGreaterOrEqual, OperatorLess, OperatorLessOrEqual, OperatorEqual, OperatorNotEqual:
+		return nil
+	}
+	return fmt.Errorf("invalid operator %q: must be one of any, none, >, >=, <, <=, ==, !=", c.Operator)
+}
+
+// compare reports whether value satisfies a comparison operator.
+func (c Condition) compare(value float64) bool {
+	switch c.Operator {
+	case OperatorGreater:
+		return value > c.Value
+	case OperatorGreaterOrEqual:
+		return value >= c.Value
+	case OperatorLess:
+		return value < c.Value
+	case OperatorLessOrEqual:
+		return value <= c.Value
+	case OperatorEqual:
+		return value == c.Value
+	case OperatorNotEqual:
+		return value != c.Value
+	}
+	return false
+}
+
+// matches reports whether the samples of a query result satisfy the condition.
+func (c Condition) matches(values []float64) bool {
+	switch c.Operator {
+	case OperatorAny:
+		return len(values) > 0
+	case OperatorNone:
+		return len(values) == 0
+	}
+	for _, value := range values {
+		if c.compare(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ProbeConfig) setDefaults() {
+	if p.Interval == 0 {
+		p.Interval = defaultProbeInterval
+	}
+	if p.Timeout == 0 {
+		p.Timeout = p.Interval
+	}
+	if p.Severity == "" {
+		p.Severity = types.SeverityDown
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = 1
+	}
+	if p.SuccessThreshold == 0 {
+		p.SuccessThreshold = 1
+	}
+	if p.Prometheus != nil {
+		if len(p.Prometheus.Thresholds) == 0 && p.Prometheus.UnhealthyWhen.Operator == "" {
+			p.Prometheus.UnhealthyWhen.Operator = OperatorAny
+		}
+		for i := range p.Prometheus.Thresholds {
+			if p.Prometheus.Thresholds[i].Severity == "" {
+				p.Prometheus.Thresholds[i].Severity = p.Severity
+			}
+		}
+		if p.Prometheus.For > 0 && p.Prometheus.Step == 0 {
+			p.Prometheus.Step = min(p.Interval, p.Prometheus.For)
+		}
+	}
+	if p.HTTP != nil {
+		if p.HTTP.Method == "" {
+			p.HTTP.Method = http.MethodGet
+		}
+		if len(p.HTTP.ExpectedStatusCodes) == 0 {
+			p.HTTP.ExpectedStatusCodes = []int{http.StatusOK}
+		}
+		if p.HTTP.Timeout == 0 {
+			p.HTTP.Timeout = min(defaultHTTPTimeout, p.Timeout/2)
+		}
+	}
+	if p.Kubernetes != nil && p.Kubernetes.Events != nil && p.Kubernetes.Events.Window == 0 {
+		p.Kubernetes.Events.Window = defaultEventWindow
+	}
+}
+
+func (p *ProbeConfig) validate() []error {
+	var errs []error
+	if p.Component == "" {
+		errs = append(errs, errors.New("component is required"))
+	}
+	if p.SubComponent == "" {
+		errs = append(errs, errors.New("sub_component is required"))
+	}
+	if p.Interval < time.Second {
+		errs = append(errs, fmt.Errorf("interval %s is too short: must be at least 1s", p.Interval))
+	}
+	if p.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid timeout %s: must not be negative", p.Timeout))
+	}
+	if !types.IsValidSeverity(string(p.Severity)) {
+		errs = append(errs, fmt.Errorf("invalid severity %q: must be one of Down, Degraded, Suspected", p.Severity))
+	}
+	if p.FailureThreshold < 1 {
+		errs = append(errs, fmt.Errorf("invalid failure_threshold %d: must be at least 1", p.FailureThreshold))
+	}
+	if p.SuccessThreshold < 1 {
+		errs = append(errs, fmt.Errorf("invalid success_threshold %d: must be at least 1", p.SuccessThreshold))
+	}
+	if p.MinOutageDuration < 0 {
+		errs = append(errs, fmt.Errorf("invalid min_outage_duration %s: must not be negative", p.MinOutageDuration))
+	}
+	if p.Flapping != nil {
+		if p.Flapping.Transitions < 2 {
+			errs = append(errs, fmt.Errorf("invalid flapping.transitions %d: must be at least 2", p.Flapping.Transitions))
+		}
+		if p.Flapping.Window <= 0 {
+			errs = append(errs, errors.New("flapping.window is required"))
+		}
+	}
+
+	switch p.Type {
+	case ProbeTypePrometheus:
+		if p.Prometheus == nil {
+			errs = append(errs, errors.New("prometheus section is required for prometheus probes"))
+			break
+		}
+		errs = append(errs, p.Prometheus.validate()...)
+	case ProbeTypeHTTP:
+		if p.HTTP == nil {
+			errs = append(errs, errors.New("http section is required for http probes"))
+			break
+		}
+		errs = append(errs, p.HTTP.validate()...)
+		if p.HTTP.Timeout >= p.Timeout {
+			errs = append(errs, fmt.Errorf("invalid http.timeout %s: must be shorter than the probe timeout of %s", p.HTTP.Timeout, p.Timeout))
+		}
+	case ProbeTypeKubernetes:
+		if p.Kubernetes == nil {
+			errs = append(errs, errors.New("kubernetes section is required for kubernetes probes"))
+			break
+		}
+		errs = append(errs, p.Kubernetes.validate()...)
+	default:
+		errs = append(errs, fmt.Errorf("invalid type %q: must be one of prometheus, http, kubernetes", p.Type))
+	}
+	return errs
+}
+
+// validateTarget checks that the sub-component the probe targets exists in the dashboard config and is managed,
+// since the dashboard only lets monitors open and resolve outages of managed sub-components.
+func (p *ProbeConfig) validateTarget(dashboard *types.Config) error {
+	for _, component := range dashboard.Components {
+		if component.Name != p.Component {
+			continue
+		}
+		subComponent := component.GetSubComponent(p.SubComponent)
+		if subComponent == nil {
+			return fmt.Errorf("sub-component %q of component %q is not in the dashboard config", p.SubComponent, p.Component)
+		}
+		if !subComponent.Managed {
+			return fmt.Errorf("sub-component %q of component %q is not managed", p.SubComponent, p.Component)
+		}
+		return nil
+	}
+	return fmt.Errorf("component %q is not in the dashboard config", p.Component)
+}
+
+// forSources returns the probe as it runs against each Prometheus source it queries. A probe with a
+// prometheus.source of * is repeated for every source and named after it, and the name of the source replaces the
+// placeholder in the sub-component.
+func (p *ProbeConfig) forSources(sources []PrometheusSource) ([]ProbeConfig, error) {
+	var source string
+	if p.Type == ProbeTypePrometheus {
+		source = p.Prometheus.Source
+	}
+	templated := strings.Contains(p.SubComponent, sourcePlaceholder)
+
+	switch source {
+	case "":
+		if templated {
+			return nil, fmt.Errorf("sub_component can only contain %s when prometheus.source is set", sourcePlaceholder)
+		}
+		return []ProbeConfig{*p}, nil
+	case allPrometheusSources:
+		if !templated {
+			return nil, fmt.Errorf("sub_component must contain %s to run against every Prometheus source", sourcePlaceholder)
+		}
+		if len(sources) == 0 {
+			return nil, errors.New("no Prometheus sources are defined to run against")
+		}
+		var probes []ProbeConfig
+		for _, s := range sources {
+			probe := p.withSource(s.Name)
+			probe.Name = fmt.Sprintf("%s/%s", p.Name, s.Name)
+			probes = append(probes, probe)
+		}
+		return probes, nil
+	}
+
+	if !slices.ContainsFunc(sources, func(s PrometheusSource) bool { return s.Name == source }) {
+		return nil, fmt.Errorf("unknown prometheus.source %q", source)
+	}
+	return []ProbeConfig{p.withSource(source)}, nil
+}
+
+// withSource returns a copy of the probe that queries the named Prometheus source.
+func (p *ProbeConfig) withSource(source string) ProbeConfig {
+	probe := *p
+	prometheus := *p.Prometheus
+	prometheus.Source = source
+	probe.Prometheus = &prometheus
+	probe.SubComponent = strings.ReplaceAll(p.SubComponent, sourcePlaceholder, source)
+	return probe
+}
+
+// decodeMonitorConfig parses a single configuration file, rejecting unknown fields so that typos are reported
+// instead of silently ignored.
+func decodeMonitorConfig(data []byte) (MonitorConfig, error) {
+	var config MonitorConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return MonitorConfig{}, err
+	}
+	return config, nil
+}
+
+// LoadMonitorConfig reads every file referred to by path, which may be a file, a directory or a glob, and merges
+// their Prometheus sources and probes. When the dashboard config is given, probes must target managed
+// sub-components in it. All problems found are returned together, each prefixed with the file and probe it came
+// from.
+func LoadMonitorConfig(path string, dashboard *types.Config) (*MonitorConfig, error) {
+	files, err := types.ConfigFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &MonitorConfig{}
+	var errs []error
+	configs := make(map[string]MonitorConfig)
+	sourceFiles := make(map[string]string)
+	probeSources := make(map[string]string)
+
+	// Sources are collected from every file first, since probes may use sources defined in other files
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to read file: %w", file, err))
+			continue
+		}
+
+		config, err := decodeMonitorConfig(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to parse file: %w", file, err))
+			continue
+		}
+		configs[file] = config
+
+		for i, source := range config.PrometheusSources {
+			if source.Name == "" {
+				errs = append(errs, fmt.Errorf("%s: prometheus_sources[%d] is missing a name", file, i))
+				continue
+			}
+			if defined, exists := sourceFiles[source.Name]; exists {
+				errs = append(errs, fmt.Errorf("%s: prometheus source %q is already defined in %s", file, source.Name, defined))
+				continue
+			}
+			sourceFiles[source.Name] = file
+
+			source.setDefaults()
+			if err := source.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: prometheus source %q: %w", file, source.Name, err))
+				continue
+			}
+			merged.PrometheusSources = append(merged.PrometheusSources, source)
+		}
+	}
+
+	for _, file := range files {
+		config, parsed := configs[file]
+		if !parsed {
+			continue
+		}
+
+		for i, probe := range config.Probes {
+			if probe.Name == "" {
+				errs = append(errs, fmt.Errorf("%s: probes[%d] is missing a name", file, i))
+				continue
+			}
+			if source, exists := probeSources[probe.Name]; exists {
+				errs = append(errs, fmt.Errorf("%s: probe %q is already defined in %s", file, probe.Name, source))
+				continue
+			}
+			probeSources[probe.Name] = file
+
+			probe.setDefaults()
+			if probeErrs := probe.validate(); len(probeErrs) > 0 {
+				for _, err := range probeErrs {
+					errs = append(errs, fmt.Errorf("%s: probe %q: %w", file, probe.Name, err))
+				}
+				continue
+			}
+			expanded, err := probe.forSources(merged.PrometheusSources)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: probe %q: %w", file, probe.Name, err))
+				continue
+			}
+			for _, sourceProbe := range expanded {
+				if dashboard != nil {
+					if err := sourceProbe.validateTarget(dashboard); err != nil {
+						errs = append(errs, fmt.Errorf("%s: probe %q: %w", file, sourceProbe.Name, err))
+						continue
+					}
+				}
+				merged.Probes = append(merged.Probes, sourceProbe)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(merged.Probes) == 0 {
+		return nil, fmt.Errorf("no probes are defined in %s", path)
+	}
+	return merged, nil
+}
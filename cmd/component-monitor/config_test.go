@@ -0,0 +1,370 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deckProbeConfig = `probes:
+  - name: deck-up
+    component: Prow
+    sub_component: Deck
+    type: prometheus
+    prometheus:
+      query: absent(up{job="deck"} == 1)
+`
+
+const tideProbeConfig = `probes:
+  - name: tide-sync-errors
+    component: Prow
+    sub_component: Tide
+    type: prometheus
+    interval: 1m
+    severity: Degraded
+    prometheus:
+      query: sum(rate(tide_sync_errors_total[5m]))
+      unhealthy_when:
+        operator: ">"
+        value: 0.5
+`
+
+const errorRatioProbeConfig = `probes:
+  - name: deck-errors
+    component: Prow
+    sub_component: Deck
+    type: prometheus
+    interval: 1m
+    prometheus:
+      query: sum(rate(deck_errors_total[5m])) / sum(rate(deck_requests_total[5m]))
+      for: 5m
+      thresholds:
+        - operator: ">"
+          value: 0.05
+          severity: Degraded
+        - operator: ">"
+          value: 0.5
+`
+
+const buildFarmSourcesConfig = `prometheus_sources:
+  - name: Build01
+    kubeconfig_context: build01
+  - name: Build02
+    url: https://thanos-querier.build02.example.com
+    bearer_token_file: /etc/build02/token
+`
+
+const buildFarmProbeConfig = `probes:
+  - name: build-farm-up
+    component: Build Farm
+    sub_component: "{source}"
+    type: prometheus
+    prometheus:
+      query: absent(up{job="prometheus-k8s"} == 1)
+      source: "*"
+  - name: build02-registry-up
+    component: Build Farm
+    sub_component: "{source}"
+    type: prometheus
+    prometheus:
+      query: absent(up{job="image-registry"} == 1)
+      source: Build02
+`
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestLoadMonitorConfig(t *testing.T) {
+	dashboard := &types.Config{
+		Components: []types.Component{
+			{
+				Name: "Prow",
+				Subcomponents: []types.SubComponent{
+					{Name: "Deck", Managed: true},
+					{Name: "Tide"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		dashboard       *types.Config
+		expected        []ProbeConfig
+		expectedSources []PrometheusSource
+		expectedErrors  []string
+	}{
+		{
+			name:  "merges probes from all files and applies defaults",
+			files: map[string]string{"a-deck.yaml": deckProbeConfig, "b-tide.yaml": tideProbeConfig},
+			expected: []ProbeConfig{
+				{
+					Name: "deck-up", Component: "Prow", SubComponent: "Deck", Type: ProbeTypePrometheus,
+					Interval: 30 * time.Second, Timeout: 30 * time.Second, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: `absent(up{job="deck"} == 1)`, UnhealthyWhen: Condition{Operator: OperatorAny}},
+				},
+				{
+					Name: "tide-sync-errors", Component: "Prow", SubComponent: "Tide", Type: ProbeTypePrometheus,
+					Interval: time.Minute, Timeout: time.Minute, Severity: types.SeverityDegraded, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: "sum(rate(tide_sync_errors_total[5m]))", UnhealthyWhen: Condition{Operator: OperatorGreater, Value: 0.5}},
+				},
+			},
+		},
+		{
+			name:  "thresholds and for",
+			files: map[string]string{"a.yaml": errorRatioProbeConfig},
+			expected: []ProbeConfig{
+				{
+					Name: "deck-errors", Component: "Prow", SubComponent: "Deck", Type: ProbeTypePrometheus,
+					Interval: time.Minute, Timeout: time.Minute, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{
+						Query: "sum(rate(deck_errors_total[5m])) / sum(rate(deck_requests_total[5m]))",
+						Thresholds: []Threshold{
+							{Condition: Condition{Operator: OperatorGreater, Value: 0.05}, Severity: types.SeverityDegraded},
+							{Condition: Condition{Operator: OperatorGreater, Value: 0.5}, Severity: types.SeverityDown},
+						},
+						For:  5 * time.Minute,
+						Step: time.Minute,
+					},
+				},
+			},
+		},
+		{
+			name:           "duplicate probe name",
+			files:          map[string]string{"a.yaml": deckProbeConfig, "b.yaml": deckProbeConfig},
+			expectedErrors: []string{`b.yaml: probe "deck-up" is already defined in`},
+		},
+		{
+			name:      "probe targeting a managed sub-component",
+			files:     map[string]string{"a.yaml": deckProbeConfig},
+			dashboard: dashboard,
+			expected: []ProbeConfig{
+				{
+					Name: "deck-up", Component: "Prow", SubComponent: "Deck", Type: ProbeTypePrometheus,
+					Interval: 30 * time.Second, Timeout: 30 * time.Second, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: `absent(up{job="deck"} == 1)`, UnhealthyWhen: Condition{Operator: OperatorAny}},
+				},
+			},
+		},
+		{
+			name: "probes targeting unmanaged or unknown sub-components",
+			files: map[string]string{
+				"a-tide.yaml":   tideProbeConfig,
+				"b-plank.yaml":  "probes:\n  - name: plank-up\n    component: Prow\n    sub_component: Plank\n    type: prometheus\n    prometheus:\n      query: up\n",
+				"c-builds.yaml": "probes:\n  - name: builds-up\n    component: Build Farm\n    sub_component: build01\n    type: prometheus\n    prometheus:\n      query: up\n",
+			},
+			dashboard: dashboard,
+			expectedErrors: []string{
+				`a-tide.yaml: probe "tide-sync-errors": sub-component "Tide" of component "Prow" is not managed`,
+				`b-plank.yaml: probe "plank-up": sub-component "Plank" of component "Prow" is not in the dashboard config`,
+				`c-builds.yaml: probe "builds-up": component "Build Farm" is not in the dashboard config`,
+			},
+		},
+		{
+			name:  "probes run against one or every Prometheus source",
+			files: map[string]string{"a-probes.yaml": buildFarmProbeConfig, "b-sources.yaml": buildFarmSourcesConfig},
+			dashboard: &types.Config{
+				Components: []types.Component{
+					{
+						Name: "Build Farm",
+						Subcomponents: []types.SubComponent{
+							{Name: "Build01", Managed: true},
+							{Name: "Build02", Managed: true},
+						},
+					},
+				},
+			},
+			expected: []ProbeConfig{
+				{
+					Name: "build-farm-up/Build01", Component: "Build Farm", SubComponent: "Build01", Type: ProbeTypePrometheus,
+					Interval: 30 * time.Second, Timeout: 30 * time.Second, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: `absent(up{job="prometheus-k8s"} == 1)`, UnhealthyWhen: Condition{Operator: OperatorAny}, Source: "Build01"},
+				},
+				{
+					Name: "build-farm-up/Build02", Component: "Build Farm", SubComponent: "Build02", Type: ProbeTypePrometheus,
+					Interval: 30 * time.Second, Timeout: 30 * time.Second, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: `absent(up{job="prometheus-k8s"} == 1)`, UnhealthyWhen: Condition{Operator: OperatorAny}, Source: "Build02"},
+				},
+				{
+					Name: "build02-registry-up", Component: "Build Farm", SubComponent: "Build02", Type: ProbeTypePrometheus,
+					Interval: 30 * time.Second, Timeout: 30 * time.Second, Severity: types.SeverityDown, FailureThreshold: 1, SuccessThreshold: 1,
+					Prometheus: &PrometheusProbeConfig{Query: `absent(up{job="image-registry"} == 1)`, UnhealthyWhen: Condition{Operator: OperatorAny}, Source: "Build02"},
+				},
+			},
+			expectedSources: []PrometheusSource{
+				{Name: "Build01", KubeconfigContext: "build01", PrometheusConnection: PrometheusConnection{Route: defaultPrometheusRoute}},
+				{Name: "Build02", PrometheusConnection: PrometheusConnection{URL: "https://thanos-querier.build02.example.com", BearerTokenFile: "/etc/build02/token"}},
+			},
+		},
+		{
+			name: "invalid Prometheus sources and probes using them",
+			files: map[string]string{
+				"a-sources.yaml": buildFarmSourcesConfig + `  - url: http://localhost:9090
+  - name: Build02
+    url: http://localhost:9090
+  - name: "*"
+    url: http://localhost:9090
+  - name: Build03
+    url: https://thanos-querier.build03.example.com
+    kubeconfig_context: build03
+`,
+				"b-probes.yaml": `probes:
+  - name: untemplated
+    component: Build Farm
+    sub_component: Build01
+    type: prometheus
+    prometheus:
+      query: up
+      source: "*"
+  - name: unknown-source
+    component: Build Farm
+    sub_component: Build05
+    type: prometheus
+    prometheus:
+      query: up
+      source: Build05
+  - name: templated-without-source
+    component: Build Farm
+    sub_component: "{source}"
+    type: prometheus
+    prometheus:
+      query: up
+`,
+			},
+			expectedErrors: []string{
+				"a-sources.yaml: prometheus_sources[2] is missing a name",
+				`a-sources.yaml: prometheus source "Build02" is already defined in`,
+				`a-sources.yaml: prometheus source "*": name "*" is reserved for probes that run against every source`,
+				`a-sources.yaml: prometheus source "Build03": exactly one of url and kubeconfig_context is required`,
+				`b-probes.yaml: probe "untemplated": sub_component must contain {source} to run against every Prometheus source`,
+				`b-probes.yaml: probe "unknown-source": unknown prometheus.source "Build05"`,
+				`b-probes.yaml: probe "templated-without-source": sub_component can only contain {source} when prometheus.source is set`,
+			},
+		},
+		{
+			name:           "no probes",
+			files:          map[string]string{"a.yaml": "probes: []\n"},
+			expectedErrors: []string{"no probes are defined"},
+		},
+		{
+			name: "all problems are reported",
+			files: map[string]string{
+				"a-broken.yaml": "probes: [",
+				"b-noname.yaml": "probes:\n  - component: Prow\n",
+				"c-invalid.yaml": `probes:
+  - name: invalid
+    type: prometheus
+    interval: 10ms
+    timeout: -1s
+    severity: Broken
+    failure_threshold: -1
+    min_outage_duration: -1m
+    flapping:
+      transitions: 1
+    prometheus:
+      unhealthy_when:
+        operator: "~"
+`,
+				"d-type.yaml":    "probes:\n  - name: unknown\n    component: Prow\n    sub_component: Deck\n    type: smoke-signals\n",
+				"e-section.yaml": "probes:\n  - name: sectionless\n    component: Prow\n    sub_component: Deck\n    type: prometheus\n",
+				"f-http.yaml":    "probes:\n  - name: deck-http\n    component: Prow\n    sub_component: Deck\n    type: http\n",
+				"h-thresholds.yaml": `probes:
+  - name: thresholds
+    component: Prow
+    sub_component: Deck
+    type: prometheus
+    prometheus:
+      query: up
+      unhealthy_when:
+        operator: any
+      for: 1m
+      step: 2m
+      thresholds:
+        - operator: "=~"
+          severity: Broken
+`,
+				"i-unknown.yaml": "probes:\n  - name: typo\n    component: Prow\n    sub_component: Deck\n    type: http\n    failure_treshold: 3\n",
+				"g-kube.yaml":    "probes:\n  - name: tide-pods\n    component: Prow\n    sub_component: Tide\n    type: kubernetes\n    kubernetes:\n      workloads:\n        - kind: DaemonSet\n          name: tide\n",
+			},
+			expectedErrors: []string{
+				"a-broken.yaml: failed to parse file",
+				"b-noname.yaml: probes[0] is missing a name",
+				`c-invalid.yaml: probe "invalid": component is required`,
+				`c-invalid.yaml: probe "invalid": sub_component is required`,
+				`c-invalid.yaml: probe "invalid": interval 10ms is too short`,
+				`c-invalid.yaml: probe "invalid": invalid timeout -1s`,
+				`c-invalid.yaml: probe "invalid": invalid severity "Broken"`,
+				`c-invalid.yaml: probe "invalid": invalid failure_threshold -1`,
+				`c-invalid.yaml: probe "invalid": invalid min_outage_duration -1m0s`,
+				`c-invalid.yaml: probe "invalid": invalid flapping.transitions 1`,
+				`c-invalid.yaml: probe "invalid": flapping.window is required`,
+				`c-invalid.yaml: probe "invalid": prometheus.query is required`,
+				`c-invalid.yaml: probe "invalid": prometheus.unhealthy_when: invalid operator "~"`,
+				`d-type.yaml: probe "unknown": invalid type "smoke-signals"`,
+				`e-section.yaml: probe "sectionless": prometheus section is required`,
+				`f-http.yaml: probe "deck-http": http section is required`,
+				`g-kube.yaml: probe "tide-pods": kubernetes.namespace is required`,
+				`h-thresholds.yaml: probe "thresholds": prometheus.unhealthy_when and prometheus.thresholds cannot be combined`,
+				`h-thresholds.yaml: probe "thresholds": prometheus.thresholds[0]: invalid operator "=~"`,
+				`h-thresholds.yaml: probe "thresholds": prometheus.thresholds[0]: invalid severity "Broken"`,
+				`h-thresholds.yaml: probe "thresholds": invalid prometheus.step 2m0s`,
+				`g-kube.yaml: probe "tide-pods": kubernetes.workloads[0]: invalid kind "DaemonSet"`,
+				"i-unknown.yaml: failed to parse file: yaml: unmarshal errors:\n  line 6: field failure_treshold not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+
+			config, err := LoadMonitorConfig(dir, tt.dashboard)
+			if len(tt.expectedErrors) > 0 {
+				require.Error(t, err)
+				for _, expected := range tt.expectedErrors {
+					assert.Contains(t, err.Error(), expected)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config.Probes)
+			assert.Equal(t, tt.expectedSources, config.PrometheusSources)
+		})
+	}
+}
+
+func TestCondition_Matches(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition Condition
+		values    []float64
+		expected  bool
+	}{
+		{name: "any with samples", condition: Condition{Operator: OperatorAny}, values: []float64{0}, expected: true},
+		{name: "any without samples", condition: Condition{Operator: OperatorAny}, expected: false},
+		{name: "none without samples", condition: Condition{Operator: OperatorNone}, expected: true},
+		{name: "greater than matched by one sample", condition: Condition{Operator: OperatorGreater, Value: 0.05}, values: []float64{0.01, 0.2}, expected: true},
+		{name: "greater than not matched", condition: Condition{Operator: OperatorGreater, Value: 0.05}, values: []float64{0.05}, expected: false},
+		{name: "less or equal", condition: Condition{Operator: OperatorLessOrEqual, Value: 1}, values: []float64{1}, expected: true},
+		{name: "comparison without samples", condition: Condition{Operator: OperatorEqual, Value: 0}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.condition.matches(tt.values))
+		})
+	}
+}
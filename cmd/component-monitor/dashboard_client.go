@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"ship-status-dash/pkg/types"
+	"strings"
+	"time"
+)
+
+// errActiveOutageExists is returned when an outage cannot be opened because the sub-component already has one.
+var errActiveOutageExists = errors.New("sub-component already has an active outage")
+
+// errOutageNotFound is returned when an outage no longer exists.
+var errOutageNotFound = errors.New("outage not found")
+
+// dashboardAPI is the part of the dashboard API used to report probe results.
+type dashboardAPI interface {
+	CreateOutage(ctx context.Context, component, subComponent string, outage types.Outage) (types.Outage, error)
+	GetOutage(ctx context.Context, component, subComponent string, id uint) (types.Outage, error)
+	GetOutages(ctx context.Context, component, subComponent string) ([]types.Outage, error)
+	ResolveOutage(ctx context.Context, component, subComponent string, id uint, endTime time.Time) (types.Outage, error)
+	UpdateSeverity(ctx context.Context, component, subComponent string, id uint, severity types.Severity) (types.Outage, error)
+}
+
+// DashboardClient calls the dashboard API, authenticating with a bearer token read from a file.
+type DashboardClient struct {
+	baseURL    string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// NewDashboardClient creates a client for the dashboard at baseURL. The token file is read on every request so
+// that rotated service account tokens are picked up; no token is sent when tokenFile is empty.
+func NewDashboardClient(baseURL, tokenFile string) *DashboardClient {
+	return &DashboardClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		tokenFile:  tokenFile,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *DashboardClient) outagesURL(component, subComponent string) string {
+	return fmt.Sprintf("%s/api/components/%s/%s/outages", c.baseURL, url.PathEscape(component), url.PathEscape(subComponent))
+}
+
+// do sends a request with a JSON body, and decodes a JSON response into out unless it is nil. Responses with
+// status codes other than expected are returned as errors along with the status code.
+func (c *DashboardClient) do(ctx context.Context, method, requestURL string, body interface{}, expected int, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokenFile != "" {
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read dashboard token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		var apiError struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiError); err != nil || apiError.Error == "" {
+			apiError.Error = http.StatusText(resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s returned %d: %s", method, requestURL, resp.StatusCode, apiError.Error)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response of %s %s: %w", method, requestURL, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// CreateOutage opens an outage. It returns errActiveOutageExists if the sub-component already has an active outage,
+// which the monitor must not take over.
+func (c *DashboardClient) CreateOutage(ctx context.Context, component, subComponent string, outage types.Outage) (types.Outage, error) {
+	var created types.Outage
+	status, err := c.do(ctx, http.MethodPost, c.outagesURL(component, subComponent), outage, http.StatusCreated, &created)
+	if status == http.StatusConflict {
+		return created, fmt.Errorf("%w: %v", errActiveOutageExists, err)
+	}
+	return created, err
+}
+
+// GetOutage retrieves an outage, returning errOutageNotFound if it has been deleted.
+func (c *DashboardClient) GetOutage(ctx context.Context, component, subComponent string, id uint) (types.Outage, error) {
+	var outage types.Outage
+	status, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s/%d", c.outagesURL(component, subComponent), id), nil, http.StatusOK, &outage)
+	if status == http.StatusNotFound {
+		return outage, fmt.Errorf("%w: %v", errOutageNotFound, err)
+	}
+	return outage, err
+}
+
+// GetOutages lists the outages of a sub-component.
+func (c *DashboardClient) GetOutages(ctx context.Context, component, subComponent string) ([]types.Outage, error) {
+	var outages []types.Outage
+	_, err := c.do(ctx, http.MethodGet, c.outagesURL(component, subComponent), nil, http.StatusOK, &outages)
+	return outages, err
+}
+
+// ResolveOutage ends an outage at endTime.
+func (c *DashboardClient) ResolveOutage(ctx context.Context, component, subComponent string, id uint, endTime time.Time) (types.Outage, error) {
+	var outage types.Outage
+	body := map[string]interface{}{"end_time": endTime.UTC()}
+	status, err := c.do(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", c.outagesURL(component, subComponent), id), body, http.StatusOK, &outage)
+	if status == http.StatusNotFound {
+		return outage, fmt.Errorf("%w: %v", errOutageNotFound, err)
+	}
+	return outage, err
+}
+
+// UpdateSeverity changes the severity of an outage.
+func (c *DashboardClient) UpdateSeverity(ctx context.Context, component, subComponent string, id uint, severity types.Severity) (types.Outage, error) {
+	var outage types.Outage
+	body := map[string]interface{}{"severity": severity}
+	status, err := c.do(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", c.outagesURL(component, subComponent), id), body, http.StatusOK, &outage)
+	if status == http.StatusNotFound {
+		return outage, fmt.Errorf("%w: %v", errOutageNotFound, err)
+	}
+	return outage, err
+}
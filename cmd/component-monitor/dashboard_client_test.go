@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardClient(t *testing.T) {
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/components/Prow/Deck/outages":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(types.Outage{DiscoveredFrom: "deck-up"})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/components/Prow/Tide/outages":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "An active outage already exists"})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/components/Prow/Deck/outages/7":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Outage not found"})
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/components/Prow/Deck/outages/3":
+			json.NewEncoder(w).Encode(types.Outage{AutoResolve: true})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+	client := NewDashboardClient(server.URL+"/", tokenFile)
+	ctx := context.Background()
+
+	created, err := client.CreateOutage(ctx, "Prow", "Deck", types.Outage{DiscoveredFrom: "deck-up", AutoResolve: true})
+	require.NoError(t, err)
+	assert.Equal(t, "deck-up", created.DiscoveredFrom)
+	assert.Equal(t, "Bearer secret", requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, true, bodies[0]["auto_resolve"])
+
+	_, err = client.CreateOutage(ctx, "Prow", "Tide", types.Outage{})
+	assert.ErrorIs(t, err, errActiveOutageExists)
+	assert.ErrorContains(t, err, "An active outage already exists")
+
+	_, err = client.GetOutage(ctx, "Prow", "Deck", 7)
+	assert.ErrorIs(t, err, errOutageNotFound)
+
+	endTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	resolved, err := client.ResolveOutage(ctx, "Prow", "Deck", 3, endTime)
+	require.NoError(t, err)
+	assert.True(t, resolved.AutoResolve)
+	assert.Equal(t, "2024-01-02T03:04:05Z", bodies[3]["end_time"])
+
+	_, err = client.UpdateSeverity(ctx, "Prow", "Deck", 3, types.SeverityDown)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPatch, requests[4].Method)
+	assert.Equal(t, map[string]interface{}{"severity": "Down"}, bodies[4])
+
+	_, err = client.GetOutages(ctx, "Prow", "Plank")
+	assert.ErrorContains(t, err, "returned 500: Internal Server Error")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// maxHTTPBodySize bounds how much of a response body is read for assertions.
+const maxHTTPBodySize = 1 << 20
+
+// HTTPProber requests an endpoint and fails when the request cannot be made or the response is not as expected.
+type HTTPProber struct {
+	client    *http.Client
+	config    HTTPProbeConfig
+	bodyRegex *regexp.Regexp
+	severity  types.Severity
+	now       func() time.Time
+}
+
+// loadCAFile returns a pool of the PEM encoded certificates in path.
+func loadCAFile(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// newHTTPProber creates an HTTPProber from a validated config.
+func newHTTPProber(config HTTPProbeConfig, severity types.Severity) (*HTTPProber, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         config.TLS.ServerName,
+		InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+	}
+	if config.TLS.CAFile != "" {
+		pool, err := loadCAFile(config.TLS.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	prober := &HTTPProber{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+		},
+		config:   config,
+		severity: severity,
+		now:      time.Now,
+	}
+	if config.BodyRegex != "" {
+		prober.bodyRegex = regexp.MustCompile(config.BodyRegex)
+	}
+	return prober, nil
+}
+
+// checkJSONPath reports why the JSON body does not satisfy the assertion, or returns nil when it does.
+func checkJSONPath(assertion JSONPathAssertion, body []byte) error {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return fmt.Errorf("response body is not JSON: %w", err)
+	}
+
+	path := jsonpath.New("")
+	if err := path.Parse(assertion.Path); err != nil {
+		return err
+	}
+	results, err := path.FindResults(data)
+	if err != nil {
+		return fmt.Errorf("%s not found in response body", assertion.Path)
+	}
+
+	var found []string
+	for _, result := range results {
+		for _, value := range result {
+			var formatted bytes.Buffer
+			if err := path.PrintResults(&formatted, []reflect.Value{value}); err != nil {
+				return err
+			}
+			found = append(found, formatted.String())
+		}
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("%s not found in response body", assertion.Path)
+	}
+	if assertion.Value != "" && !slices.Contains(found, assertion.Value) {
+		return fmt.Errorf("%s is %s, expected %s", assertion.Path, strings.Join(found, ", "), assertion.Value)
+	}
+	return nil
+}
+
+// check reports why the response does not satisfy the assertions, or returns nil when it does.
+func (p *HTTPProber) check(resp *http.Response) error {
+	if !slices.Contains(p.config.ExpectedStatusCodes, resp.StatusCode) {
+		return fmt.Errorf("returned status %d, expected %s", resp.StatusCode, formatStatusCodes(p.config.ExpectedStatusCodes))
+	}
+	if p.bodyRegex == nil && p.config.JSONPath == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodySize))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if p.bodyRegex != nil && !p.bodyRegex.Match(body) {
+		return fmt.Errorf("response body does not match %s", p.config.BodyRegex)
+	}
+	if p.config.JSONPath != nil {
+		return checkJSONPath(*p.config.JSONPath, body)
+	}
+	return nil
+}
+
+func formatStatusCodes(codes []int) string {
+	formatted := make([]string, 0, len(codes))
+	for _, code := range codes {
+		formatted = append(formatted, fmt.Sprint(code))
+	}
+	return strings.Join(formatted, " or ")
+}
+
+// Probe requests the endpoint once. Failing to reach the endpoint is an unhealthy result rather than an error,
+// since for an endpoint that is what an outage looks like.
+func (p *HTTPProber) Probe(ctx context.Context) (ProbeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, p.config.Method, p.config.URL, nil)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	for name, value := range p.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	start := p.now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ProbeResult{}, ctx.Err()
+		}
+		message := fmt.Sprintf("%s %s failed: %v", p.config.Method, p.config.URL, err)
+		var timeoutErr interface{ Timeout() bool }
+		if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+			message = fmt.Sprintf("%s %s timed out after %s", p.config.Method, p.config.URL, p.config.Timeout)
+		}
+		return ProbeResult{Severity: p.severity, Message: message}, nil
+	}
+	defer resp.Body.Close()
+
+	if err := p.check(resp); err != nil {
+		return ProbeResult{Severity: p.severity, Message: fmt.Sprintf("%s %s %v", p.config.Method, p.config.URL, err)}, nil
+	}
+
+	latency := p.now().Sub(start)
+	message := fmt.Sprintf("%s %s returned status %d in %s", p.config.Method, p.config.URL, resp.StatusCode, latency.Round(time.Millisecond))
+	if p.config.DegradedLatency > 0 && latency > p.config.DegradedLatency {
+		// A slow response is never reported as more severe than a failed one
+		severity := types.SeverityDegraded
+		if types.GetSeverityLevel(p.severity) < types.GetSeverityLevel(severity) {
+			severity = p.severity
+		}
+		return ProbeResult{Severity: severity, Message: message + fmt.Sprintf(", which is slower than %s", p.config.DegradedLatency)}, nil
+	}
+	return ProbeResult{Message: message}, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProber_Probe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.Write([]byte("ok"))
+		case "/status":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status": "green", "checks": [{"name": "db", "healthy": true}, {"name": "cache", "healthy": false}]}`))
+		case "/auth":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("welcome"))
+		case "/create":
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		config   HTTPProbeConfig
+		severity types.Severity
+		expected types.Severity
+		message  string
+	}{
+		{
+			name:    "expected status",
+			config:  HTTPProbeConfig{URL: server.URL + "/healthz"},
+			message: "returned status 200",
+		},
+		{
+			name:     "unexpected status",
+			config:   HTTPProbeConfig{URL: server.URL + "/broken"},
+			expected: types.SeverityDown,
+			message:  "returned status 503, expected 200",
+		},
+		{
+			name:    "method and expected status codes",
+			config:  HTTPProbeConfig{URL: server.URL + "/create", Method: http.MethodPost, ExpectedStatusCodes: []int{http.StatusOK, http.StatusCreated}},
+			message: "returned status 201",
+		},
+		{
+			name:    "headers",
+			config:  HTTPProbeConfig{URL: server.URL + "/auth", Headers: map[string]string{"Authorization": "Bearer secret"}},
+			message: "returned status 200",
+		},
+		{
+			name:    "body regex matched",
+			config:  HTTPProbeConfig{URL: server.URL + "/auth", Headers: map[string]string{"Authorization": "Bearer secret"}, BodyRegex: "^welcome$"},
+			message: "returned status 200",
+		},
+		{
+			name:     "body regex not matched",
+			config:   HTTPProbeConfig{URL: server.URL + "/healthz", BodyRegex: "^healthy$"},
+			expected: types.SeverityDown,
+			message:  "response body does not match ^healthy$",
+		},
+		{
+			name:    "json path value",
+			config:  HTTPProbeConfig{URL: server.URL + "/status", JSONPath: &JSONPathAssertion{Path: "{.status}", Value: "green"}},
+			message: "returned status 200",
+		},
+		{
+			name:     "json path value mismatch",
+			config:   HTTPProbeConfig{URL: server.URL + "/status", JSONPath: &JSONPathAssertion{Path: `{.checks[?(@.name=="cache")].healthy}`, Value: "true"}},
+			expected: types.SeverityDown,
+			message:  `{.checks[?(@.name=="cache")].healthy} is false, expected true`,
+		},
+		{
+			name:     "json path missing",
+			config:   HTTPProbeConfig{URL: server.URL + "/status", JSONPath: &JSONPathAssertion{Path: "{.version}"}},
+			expected: types.SeverityDown,
+			message:  "{.version} not found in response body",
+		},
+		{
+			name:     "json path on a body that is not JSON",
+			config:   HTTPProbeConfig{URL: server.URL + "/healthz", JSONPath: &JSONPathAssertion{Path: "{.status}"}},
+			expected: types.SeverityDown,
+			message:  "response body is not JSON",
+		},
+		{
+			name:     "slower than the degraded latency",
+			config:   HTTPProbeConfig{URL: server.URL + "/slow", DegradedLatency: 50 * time.Millisecond},
+			expected: types.SeverityDegraded,
+			message:  "which is slower than 50ms",
+		},
+		{
+			name:     "slower than the degraded latency with a Suspected probe",
+			config:   HTTPProbeConfig{URL: server.URL + "/slow", DegradedLatency: 50 * time.Millisecond},
+			severity: types.SeveritySuspected,
+			expected: types.SeveritySuspected,
+			message:  "which is slower than 50ms",
+		},
+		{
+			name:     "timeout",
+			config:   HTTPProbeConfig{URL: server.URL + "/slow", Timeout: 50 * time.Millisecond},
+			expected: types.SeverityDown,
+			message:  "timed out after 50ms",
+		},
+		{
+			name:     "connection refused",
+			config:   HTTPProbeConfig{URL: "http://127.0.0.1:1/healthz"},
+			expected: types.SeverityDown,
+			message:  "GET http://127.0.0.1:1/healthz failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probeConfig := ProbeConfig{Type: ProbeTypeHTTP, HTTP: &tt.config}
+			probeConfig.setDefaults()
+			require.Empty(t, probeConfig.HTTP.validate())
+
+			severity := tt.severity
+			if severity == "" {
+				severity = types.SeverityDown
+			}
+			prober, err := newHTTPProber(*probeConfig.HTTP, severity)
+			require.NoError(t, err)
+
+			result, err := prober.Probe(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Severity)
+			assert.Contains(t, result.Message, tt.message)
+		})
+	}
+}
+
+func TestHTTPProber_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644))
+
+	tests := []struct {
+		name     string
+		tls      HTTPTLSConfig
+		expected types.Severity
+	}{
+		{name: "untrusted certificate", expected: types.SeverityDown},
+		{name: "custom CA", tls: HTTPTLSConfig{CAFile: caFile}},
+		{name: "custom CA with a server name the certificate is not valid for", tls: HTTPTLSConfig{CAFile: caFile, ServerName: "deck.ci.internal"}, expected: types.SeverityDown},
+		{name: "verification disabled", tls: HTTPTLSConfig{InsecureSkipVerify: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober, err := newHTTPProber(HTTPProbeConfig{URL: server.URL, Method: http.MethodGet, ExpectedStatusCodes: []int{http.StatusOK}, Timeout: time.Second, TLS: tt.tls}, types.SeverityDown)
+			require.NoError(t, err)
+
+			result, err := prober.Probe(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Severity, result.Message)
+		})
+	}
+
+	emptyCA := filepath.Join(t.TempDir(), "empty.crt")
+	require.NoError(t, os.WriteFile(emptyCA, []byte("not a certificate"), 0644))
+	_, err := newHTTPProber(HTTPProbeConfig{URL: server.URL, TLS: HTTPTLSConfig{CAFile: emptyCA}}, types.SeverityDown)
+	assert.ErrorContains(t, err, "no certificates found")
+}
+
+func TestHTTPProbeConfig_Validate(t *testing.T) {
+	config := HTTPProbeConfig{
+		URL:                 "ftp://deck",
+		Method:              "get",
+		ExpectedStatusCodes: []int{42},
+		BodyRegex:           "(",
+		JSONPath:            &JSONPathAssertion{Path: "{.status"},
+		Timeout:             time.Second,
+		DegradedLatency:     2 * time.Second,
+	}
+
+	var messages []string
+	for _, err := range config.validate() {
+		messages = append(messages, err.Error())
+	}
+	all := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		`invalid http.url "ftp://deck"`,
+		`invalid http.method "get"`,
+		"invalid http.expected_status_codes entry 42",
+		"invalid http.body_regex",
+		"invalid http.json_path.path",
+		"invalid http.degraded_latency 2s",
+	} {
+		assert.Contains(t, all, expected)
+	}
+}
+
+func TestProbeConfig_ValidateHTTPTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        ProbeConfig
+		expectedHTTP  time.Duration
+		expectedError string
+	}{
+		{
+			name:         "default within the probe timeout",
+			config:       ProbeConfig{Interval: time.Minute},
+			expectedHTTP: 10 * time.Second,
+		},
+		{
+			name:         "default within a short probe timeout",
+			config:       ProbeConfig{Interval: time.Minute, Timeout: 5 * time.Second},
+			expectedHTTP: 2500 * time.Millisecond,
+		},
+		{
+			name:          "not shorter than the probe timeout",
+			config:        ProbeConfig{Interval: time.Minute, Timeout: 10 * time.Second, HTTP: &HTTPProbeConfig{Timeout: 10 * time.Second}},
+			expectedHTTP:  10 * time.Second,
+			expectedError: "invalid http.timeout 10s: must be shorter than the probe timeout of 10s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Component, tt.config.SubComponent, tt.config.Type = "Prow", "Deck", ProbeTypeHTTP
+			if tt.config.HTTP == nil {
+				tt.config.HTTP = &HTTPProbeConfig{}
+			}
+			tt.config.HTTP.URL = "https://deck.example.com/healthz"
+			tt.config.setDefaults()
+			assert.Equal(t, tt.expectedHTTP, tt.config.HTTP.Timeout)
+
+			errs := tt.config.validate()
+			if tt.expectedError != "" {
+				require.Len(t, errs, 1)
+				assert.EqualError(t, errs[0], tt.expectedError)
+				return
+			}
+			assert.Empty(t, errs)
+		})
+	}
+}
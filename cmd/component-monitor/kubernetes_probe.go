@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// crashLoopBackOff is the waiting reason of containers that keep crashing.
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// KubernetesProber checks workloads, pods and events in a namespace.
+type KubernetesProber struct {
+	client   kubernetes.Interface
+	config   KubernetesProbeConfig
+	severity types.Severity
+	now      func() time.Time
+}
+
+// desiredReplicas returns the replica count of a workload spec, which defaults to 1 when unset.
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// checkWorkload returns a problem description when the workload does not have enough available replicas.
+func (p *KubernetesProber) checkWorkload(ctx context.Context, check WorkloadCheck) (string, error) {
+	var desired, available int32
+	var err error
+	switch check.Kind {
+	case WorkloadKindDeployment:
+		var deployment *appsv1.Deployment
+		if deployment, err = p.client.AppsV1().Deployments(p.config.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			desired, available = desiredReplicas(deployment.Spec.Replicas), deployment.Status.AvailableReplicas
+		}
+	case WorkloadKindStatefulSet:
+		var statefulSet *appsv1.StatefulSet
+		if statefulSet, err = p.client.AppsV1().StatefulSets(p.config.Namespace).Get(ctx, check.Name, metav1.GetOptions{}); err == nil {
+			desired, available = desiredReplicas(statefulSet.Spec.Replicas), statefulSet.Status.AvailableReplicas
+		}
+	}
+	if kerrors.IsNotFound(err) {
+		return fmt.Sprintf("%s %s/%s not found", check.Kind, p.config.Namespace, check.Name), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s %s/%s: %w", check.Kind, p.config.Namespace, check.Name, err)
+	}
+
+	minAvailable := desired
+	if check.MinAvailable != nil {
+		minAvailable = *check.MinAvailable
+	}
+	if available < minAvailable {
+		return fmt.Sprintf("%s %s/%s has %d available replicas, expected at least %d", check.Kind, p.config.Namespace, check.Name, available, minAvailable), nil
+	}
+	return "", nil
+}
+
+// isCrashLooping reports whether any container of the pod is in CrashLoopBackOff.
+func isCrashLooping(pod corev1.Pod) bool {
+	statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCrashLoopingPods returns a problem description when any selected pod is crash-looping.
+func (p *KubernetesProber) checkCrashLoopingPods(ctx context.Context, selector PodSelector) (string, error) {
+	pods, err := p.client.CoreV1().Pods(p.config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.Selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods matching %s: %w", selector.Selector, err)
+	}
+
+	var crashing []string
+	for _, pod := range pods.Items {
+		if isCrashLooping(pod) {
+			crashing = append(crashing, pod.Name)
+		}
+	}
+	if len(crashing) == 0 {
+		return "", nil
+	}
+	slices.Sort(crashing)
+	return fmt.Sprintf("pods in %s are crash-looping: %s", p.config.Namespace, strings.Join(crashing, ", ")), nil
+}
+
+// eventTime returns when an event was last seen.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// checkEvents returns a problem description when matching Warning events were recorded within the window.
+func (p *KubernetesProber) checkEvents(ctx context.Context, check EventCheck) (string, error) {
+	selector := fields.Set{"type": corev1.EventTypeWarning}
+	if check.InvolvedObject != "" {
+		selector["involvedObject.name"] = check.InvolvedObject
+	}
+	events, err := p.client.CoreV1().Events(p.config.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector.AsSelector().String()})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events: %w", err)
+	}
+
+	since := p.now().Add(-check.Window)
+	var found []string
+	for _, event := range events.Items {
+		// Field selectors are not honored everywhere, so the type is checked again
+		if event.Type != corev1.EventTypeWarning || !slices.Contains(check.Reasons, event.Reason) || eventTime(event).Before(since) {
+			continue
+		}
+		if check.InvolvedObject != "" && event.InvolvedObject.Name != check.InvolvedObject {
+			continue
+		}
+		found = append(found, fmt.Sprintf("%s on %s/%s: %s", event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message))
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	slices.Sort(found)
+	return fmt.Sprintf("warning events in %s in the last %s: %s", p.config.Namespace, check.Window, strings.Join(found, "; ")), nil
+}
+
+// Probe runs every configured check once. It fails when any check finds a problem, and returns an error when a
+// check could not be run.
+func (p *KubernetesProber) Probe(ctx context.Context) (ProbeResult, error) {
+	var problems []string
+	record := func(problem string, err error) error {
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+		return err
+	}
+
+	for _, workload := range p.config.Workloads {
+		if err := record(p.checkWorkload(ctx, workload)); err != nil {
+			return ProbeResult{}, err
+		}
+	}
+	if p.config.CrashLoopingPods != nil {
+		if err := record(p.checkCrashLoopingPods(ctx, *p.config.CrashLoopingPods)); err != nil {
+			return ProbeResult{}, err
+		}
+	}
+	if p.config.Events != nil {
+		if err := record(p.checkEvents(ctx, *p.config.Events)); err != nil {
+			return ProbeResult{}, err
+		}
+	}
+
+	if len(problems) > 0 {
+		return ProbeResult{Severity: p.severity, Message: strings.Join(problems, "; ")}, nil
+	}
+	return ProbeResult{Message: fmt.Sprintf("Workloads in %s are healthy", p.config.Namespace)}, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func deployment(name string, replicas, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ci"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+	}
+}
+
+func pod(name, app, waitingReason string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ci", Labels: map[string]string{"app": app}},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: app}}},
+	}
+	if waitingReason != "" {
+		pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: waitingReason}
+	}
+	return pod
+}
+
+func warningEvent(name, reason, object string, at time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "ci"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        "something went wrong",
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: object},
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+func TestKubernetesProber_Probe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		config   KubernetesProbeConfig
+		objects  []runtime.Object
+		expected ProbeResult
+	}{
+		{
+			name:     "deployment with all replicas available",
+			config:   KubernetesProbeConfig{Workloads: []WorkloadCheck{{Kind: WorkloadKindDeployment, Name: "tide"}}},
+			objects:  []runtime.Object{deployment("tide", 2, 2)},
+			expected: ProbeResult{Message: "Workloads in ci are healthy"},
+		},
+		{
+			name:     "deployment missing replicas",
+			config:   KubernetesProbeConfig{Workloads: []WorkloadCheck{{Kind: WorkloadKindDeployment, Name: "tide"}}},
+			objects:  []runtime.Object{deployment("tide", 2, 1)},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "Deployment ci/tide has 1 available replicas, expected at least 2"},
+		},
+		{
+			name:     "deployment above min available",
+			config:   KubernetesProbeConfig{Workloads: []WorkloadCheck{{Kind: WorkloadKindDeployment, Name: "tide", MinAvailable: int32Ptr(1)}}},
+			objects:  []runtime.Object{deployment("tide", 2, 1)},
+			expected: ProbeResult{Message: "Workloads in ci are healthy"},
+		},
+		{
+			name:     "missing deployment",
+			config:   KubernetesProbeConfig{Workloads: []WorkloadCheck{{Kind: WorkloadKindDeployment, Name: "crier"}}},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "Deployment ci/crier not found"},
+		},
+		{
+			name:   "stateful set without available replicas",
+			config: KubernetesProbeConfig{Workloads: []WorkloadCheck{{Kind: WorkloadKindStatefulSet, Name: "ghproxy"}}},
+			objects: []runtime.Object{&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ghproxy", Namespace: "ci"},
+				Status:     appsv1.StatefulSetStatus{AvailableReplicas: 0},
+			}},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "StatefulSet ci/ghproxy has 0 available replicas, expected at least 1"},
+		},
+		{
+			name:   "crash-looping pods",
+			config: KubernetesProbeConfig{CrashLoopingPods: &PodSelector{Selector: "app=crier"}},
+			objects: []runtime.Object{
+				pod("crier-b", "crier", crashLoopBackOff),
+				pod("crier-a", "crier", crashLoopBackOff),
+				pod("crier-c", "crier", ""),
+				pod("tide-a", "tide", crashLoopBackOff),
+			},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "pods in ci are crash-looping: crier-a, crier-b"},
+		},
+		{
+			name:     "pods waiting for other reasons",
+			config:   KubernetesProbeConfig{CrashLoopingPods: &PodSelector{Selector: "app=crier"}},
+			objects:  []runtime.Object{pod("crier-a", "crier", "ContainerCreating")},
+			expected: ProbeResult{Message: "Workloads in ci are healthy"},
+		},
+		{
+			name:   "recent warning events",
+			config: KubernetesProbeConfig{Events: &EventCheck{Reasons: []string{"FailedMount"}, Window: 10 * time.Minute}},
+			objects: []runtime.Object{
+				warningEvent("recent", "FailedMount", "tide-a", now.Add(-time.Minute)),
+				warningEvent("old", "FailedMount", "tide-b", now.Add(-time.Hour)),
+				warningEvent("other", "BackOff", "tide-c", now.Add(-time.Minute)),
+			},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "warning events in ci in the last 10m0s: FailedMount on Pod/tide-a: something went wrong"},
+		},
+		{
+			name:     "warning events about other objects",
+			config:   KubernetesProbeConfig{Events: &EventCheck{Reasons: []string{"FailedMount"}, InvolvedObject: "crier-a", Window: 10 * time.Minute}},
+			objects:  []runtime.Object{warningEvent("recent", "FailedMount", "tide-a", now.Add(-time.Minute))},
+			expected: ProbeResult{Message: "Workloads in ci are healthy"},
+		},
+		{
+			name: "problems of all checks are combined",
+			config: KubernetesProbeConfig{
+				Workloads:        []WorkloadCheck{{Kind: WorkloadKindDeployment, Name: "crier"}},
+				CrashLoopingPods: &PodSelector{Selector: "app=crier"},
+			},
+			objects:  []runtime.Object{deployment("crier", 1, 0), pod("crier-a", "crier", crashLoopBackOff)},
+			expected: ProbeResult{Severity: types.SeverityDown, Message: "Deployment ci/crier has 0 available replicas, expected at least 1; pods in ci are crash-looping: crier-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Namespace = "ci"
+			prober := &KubernetesProber{
+				client:   fake.NewSimpleClientset(tt.objects...),
+				config:   tt.config,
+				severity: types.SeverityDown,
+				now:      func() time.Time { return now },
+			}
+
+			result, err := prober.Probe(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestKubernetesProber_ProbeError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	prober := &KubernetesProber{
+		client:   client,
+		config:   KubernetesProbeConfig{Namespace: "ci", CrashLoopingPods: &PodSelector{Selector: "app=crier"}},
+		severity: types.SeverityDown,
+		now:      time.Now,
+	}
+
+	_, err := prober.Probe(context.Background())
+	assert.ErrorContains(t, err, "failed to list pods matching app=crier: forbidden")
+}
@@ -2,138 +2,210 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
 	"os"
-	"time"
+	"os/signal"
+	"ship-status-dash/pkg/types"
+	"syscall"
 
-	routeclientset "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
-	"github.com/prometheus/client_golang/api"
-	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
-	"github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/transport"
 )
 
-// PrometheusClient wraps the Prometheus API client for querying metrics.
-type PrometheusClient struct {
-	api v1.API
-}
-
-// NewPrometheusClient creates a new Prometheus client configured for OpenShift monitoring.
-func NewPrometheusClient(prometheusURL string) (*PrometheusClient, error) {
+// loadKubeconfig loads a context of the kubeconfig named by $KUBECONFIG, or /etc/kubeconfig/config. The current
+// context is loaded when context is empty.
+func loadKubeconfig(context string) (*rest.Config, error) {
 	kubeconfigPath := os.Getenv("KUBECONFIG")
 	if kubeconfigPath == "" {
 		kubeconfigPath = "/etc/kubeconfig/config"
 	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
-	if err != nil {
-		return nil, err
-	}
+// Options contains command-line configuration options for the component monitor.
+type Options struct {
+	ConfigPath          string
+	DashboardConfigPath string
+	Prometheus          PrometheusConnection
+	DashboardURL        string
+	DashboardTokenFile  string
+	Identity            string
+	Concurrency         int
+	Jitter              float64
+}
 
-	routeClient, err := routeclientset.NewForConfig(config)
-	if err != nil {
-		return nil, err
-	}
+// NewOptions parses command-line flags and returns a new Options instance.
+func NewOptions() *Options {
+	opts := &Options{}
+
+	flag.StringVar(&opts.ConfigPath, "config", "", "Path to probe config file, directory of probe config files, or glob matching probe config files")
+	flag.StringVar(&opts.DashboardConfigPath, "dashboard-config", "", "Path to the dashboard config, used to check that probes target managed sub-components (required with --dashboard-url)")
+	flag.StringVar(&opts.Prometheus.URL, "prometheus-url", os.Getenv("PROMETHEUS_URL"), "URL of the Prometheus or Thanos querier API (defaults to $PROMETHEUS_URL); the URL of --prometheus-route is discovered when empty")
+	flag.StringVar(&opts.Prometheus.Route, "prometheus-route", defaultPrometheusRoute, "Namespace/name of the OpenShift route of the Prometheus API, discovered with the kubeconfig when --prometheus-url is empty")
+	flag.StringVar(&opts.Prometheus.BearerTokenFile, "prometheus-bearer-token-file", "", "Path to the bearer token used to authenticate with the Prometheus API")
+	flag.StringVar(&opts.Prometheus.Username, "prometheus-username", "", "Username used to authenticate with the Prometheus API using basic auth")
+	flag.StringVar(&opts.Prometheus.PasswordFile, "prometheus-password-file", "", "Path to the password used to authenticate with the Prometheus API using basic auth")
+	flag.StringVar(&opts.Prometheus.CAFile, "prometheus-ca-file", "", "Path to the CA bundle trusted to serve the Prometheus API instead of the system CAs")
+	flag.BoolVar(&opts.Prometheus.InClusterConfig, "prometheus-in-cluster-config", false, "Authenticate with the Prometheus API as the service account of the pod, trusting the cluster CA")
+	flag.StringVar(&opts.DashboardURL, "dashboard-url", os.Getenv("DASHBOARD_URL"), "URL of the dashboard to report outages to (defaults to $DASHBOARD_URL); probe results are only logged when empty")
+	flag.StringVar(&opts.DashboardTokenFile, "dashboard-token-file", "/var/run/secrets/kubernetes.io/serviceaccount/token", "Path to the bearer token used to authenticate with the dashboard")
+	flag.StringVar(&opts.Identity, "identity", "", "User the dashboard authenticates the monitor as, used to find the outages it opened before restarting")
+	flag.IntVar(&opts.Concurrency, "concurrency", 10, "Maximum number of probes that run at the same time")
+	flag.Float64Var(&opts.Jitter, "jitter", 0.1, "Fraction of its interval by which the wait before each run of a probe is randomly lengthened or shortened")
+	flag.Parse()
+
+	return opts
+}
 
-	route, err := routeClient.Routes("openshift-monitoring").Get(context.Background(), "prometheus-k8s", metav1.GetOptions{})
-	if err != nil {
-		return nil, err
+// Validate checks that all required options are provided and valid.
+func (o *Options) Validate() error {
+	if o.ConfigPath == "" {
+		return errors.New("config path is required (use --config flag)")
 	}
 
-	var addr string
-	if route.Spec.TLS != nil {
-		addr = "https://" + route.Spec.Host
-	} else {
-		addr = "http://" + route.Spec.Host
+	if _, err := types.ConfigFiles(o.ConfigPath); err != nil {
+		return err
 	}
 
-	client, err := api.NewClient(api.Config{
-		Address:      addr,
-		RoundTripper: transport.NewBearerAuthRoundTripper(config.BearerToken, api.DefaultRoundTripper),
-	})
-	if err != nil {
-		return nil, err
+	if err := o.Prometheus.Validate(); err != nil {
+		return err
 	}
 
-	return &PrometheusClient{
-		api: v1.NewAPI(client),
-	}, nil
-}
-
-// QueryMetrics executes a list of Prometheus queries and logs the results.
-func (p *PrometheusClient) QueryMetrics(ctx context.Context, queries []string) error {
-	for _, query := range queries {
-		logrus.Infof("Executing query: %s", query)
+	if o.Concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
 
-		result, warnings, err := p.api.Query(ctx, query, time.Now())
-		if err != nil {
-			logrus.Errorf("Query failed: %v", err)
-			continue
-		}
+	if o.Jitter < 0 || o.Jitter >= 1 {
+		return errors.New("jitter must be at least 0 and less than 1")
+	}
 
-		if len(warnings) > 0 {
-			logrus.Warnf("Query warnings: %v", warnings)
+	if o.DashboardURL != "" {
+		if _, err := url.ParseRequestURI(o.DashboardURL); err != nil {
+			return fmt.Errorf("invalid dashboard URL: %w", err)
 		}
-
-		switch v := result.(type) {
-		case model.Vector:
-			logrus.Infof("Vector result: %d samples", len(v))
-			for _, sample := range v {
-				logrus.Infof("Sample: %s = %f", sample.Metric, float64(sample.Value))
-			}
-		case *model.Scalar:
-			logrus.Infof("Scalar result: %f", float64(v.Value))
-		case model.Matrix:
-			logrus.Infof("Matrix result: %d series", len(v))
-			for _, series := range v {
-				logrus.Infof("Series: %s (%d points)", series.Metric, len(series.Values))
-			}
-		default:
-			logrus.Infof("Unknown result type: %T", result)
+		if o.DashboardConfigPath == "" {
+			return errors.New("dashboard config path is required when reporting to the dashboard (use --dashboard-config flag)")
 		}
 	}
 
 	return nil
 }
 
+// runProbe runs a probe once, logs the result and, once smoothed by the probe state, reports it to the dashboard
+// unless reporter is nil.
+func runProbe(ctx context.Context, p *probe, reporter *outageReporter) {
+	logger := logrus.WithFields(logrus.Fields{
+		"probe":         p.config.Name,
+		"component":     p.config.Component,
+		"sub_component": p.config.SubComponent,
+	})
+
+	result, err := p.prober.Probe(ctx)
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		// A probe that does not finish in time is as unhealthy as one that fails. The outage is reported without the
+		// deadline of the probe, which has already passed.
+		result = ProbeResult{Severity: p.config.Severity, Message: fmt.Sprintf("Probe timed out after %s", p.config.Timeout)}
+		ctx = context.WithoutCancel(ctx)
+	case err != nil:
+		logger.WithField("error", err).Error("Probe failed to run")
+		return
+	}
+	if result.Healthy() {
+		logger.Info(result.Message)
+	} else {
+		logger.WithField("severity", result.Severity).Warn(result.Message)
+	}
+
+	reported := p.state.Observe(result)
+	if reporter != nil {
+		reporter.Report(ctx, p, reported)
+	}
+}
+
 func main() {
 	logrus.SetLevel(logrus.InfoLevel)
 	logrus.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
 
-	prometheusURL := os.Getenv("PROMETHEUS_URL")
-	if prometheusURL == "" {
-		prometheusURL = "http://localhost:9090"
+	opts := NewOptions()
+	if err := opts.Validate(); err != nil {
+		logrus.WithField("error", err).Fatal("Invalid command-line options")
 	}
 
-	client, err := NewPrometheusClient(prometheusURL)
+	var dashboardConfig *types.Config
+	if opts.DashboardConfigPath != "" {
+		var err error
+		dashboardConfig, err = types.LoadConfig(opts.DashboardConfigPath)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"dashboard_config_path": opts.DashboardConfigPath,
+				"error":                 err,
+			}).Fatal("Failed to load dashboard config")
+		}
+	}
+
+	config, err := LoadMonitorConfig(opts.ConfigPath, dashboardConfig)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"prometheus_url": prometheusURL,
-			"error":          err,
-		}).Fatal("Failed to create Prometheus client")
+			"config_path": opts.ConfigPath,
+			"error":       err,
+		}).Fatal("Failed to load probe config")
 	}
 
-	queries := []string{
-		"1",
-		"absent(up{job=\"deck\"} == 1)",
-		"absent(up{job=\"crier\"} == 1)",
+	// A kubeconfig is only needed for Kubernetes probes and Prometheus route discovery, so that the monitor can run
+	// against a local Prometheus without one
+	var kubeClient kubernetes.Interface
+	kubeconfig, err := loadKubeconfig("")
+	if err != nil {
+		logrus.WithField("error", err).Warn("Failed to load kubeconfig, Kubernetes probes and Prometheus route discovery are unavailable")
+	} else {
+		kubeClient, err = kubernetes.NewForConfig(kubeconfig)
+		if err != nil {
+			logrus.WithField("error", err).Fatal("Failed to create Kubernetes client")
+		}
 	}
 
-	ctx := context.Background()
+	prometheusClients, err := newPrometheusClients(config, opts.Prometheus, kubeconfig, loadKubeconfig)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"prometheus_url":   opts.Prometheus.URL,
+			"prometheus_route": opts.Prometheus.Route,
+			"error":            err,
+		}).Fatal("Failed to create Prometheus clients")
+	}
 
-	logrus.Info("Starting Prometheus metrics monitoring...")
+	probes, err := newProbes(config, probeClients{prometheus: prometheusClients, kubernetes: kubeClient})
+	if err != nil {
+		logrus.WithField("error", err).Fatal("Failed to create probes")
+	}
 
-	for {
-		logrus.Info("Executing Prometheus queries...")
-		if err := client.QueryMetrics(ctx, queries); err != nil {
-			logrus.WithField("error", err).Error("Failed to query metrics")
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-		logrus.Info("Sleeping for 30 seconds...")
-		time.Sleep(30 * time.Second)
+	var reporter *outageReporter
+	if opts.DashboardURL != "" {
+		reporter = newOutageReporter(NewDashboardClient(opts.DashboardURL, opts.DashboardTokenFile), opts.Identity)
+		if err := reporter.Recover(ctx, probes); err != nil {
+			logrus.WithField("error", err).Warn("Failed to find outages opened before restarting")
+		}
+	} else {
+		logrus.Warn("No dashboard URL is configured, probe results will only be logged")
 	}
+
+	logrus.Infof("Starting component monitor with %d probes...", len(probes))
+	scheduler := NewScheduler(probes, func(ctx context.Context, p *probe) {
+		runProbe(ctx, p, reporter)
+	}, opts.Concurrency, opts.Jitter)
+	scheduler.Run(ctx)
+	logrus.Info("Component monitor stopped")
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"ship-status-dash/pkg/types"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProbeResult is the outcome of running a probe once.
+type ProbeResult struct {
+	// Severity is empty when the sub-component is healthy.
+	Severity types.Severity
+	// Message describes what the probe observed.
+	Message string
+	// Value is the measurement that decided the result, for probes that measure one.
+	Value *float64
+}
+
+// Healthy reports whether the probe found the sub-component healthy.
+func (r ProbeResult) Healthy() bool {
+	return r.Severity == ""
+}
+
+// Prober checks the health of a sub-component. An error means that the health could not be determined, which is
+// not the same as the sub-component being unhealthy.
+type Prober interface {
+	Probe(ctx context.Context) (ProbeResult, error)
+}
+
+// probeClients holds the clients that probers are built with.
+type probeClients struct {
+	// prometheus holds a client for each Prometheus source, where the empty name is the Prometheus configured with
+	// flags.
+	prometheus map[string]v1.API
+	kubernetes kubernetes.Interface
+}
+
+// probe is a configured health check of a sub-component.
+type probe struct {
+	config ProbeConfig
+	prober Prober
+	state  *probeState
+}
+
+// newProbes builds the prober of each configured probe.
+func newProbes(config *MonitorConfig, clients probeClients) ([]*probe, error) {
+	var probes []*probe
+	for _, probeConfig := range config.Probes {
+		var prober Prober
+		switch probeConfig.Type {
+		case ProbeTypePrometheus:
+			api, ok := clients.prometheus[probeConfig.Prometheus.Source]
+			if !ok {
+				return nil, fmt.Errorf("probe %q: no Prometheus client is configured", probeConfig.Name)
+			}
+			prober = &PrometheusProber{
+				api:        api,
+				config:     *probeConfig.Prometheus,
+				thresholds: probeConfig.Prometheus.thresholds(probeConfig.Severity),
+				now:        time.Now,
+			}
+		case ProbeTypeHTTP:
+			httpProber, err := newHTTPProber(*probeConfig.HTTP, probeConfig.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("probe %q: %w", probeConfig.Name, err)
+			}
+			prober = httpProber
+		case ProbeTypeKubernetes:
+			if clients.kubernetes == nil {
+				return nil, fmt.Errorf("probe %q: no Kubernetes client is configured", probeConfig.Name)
+			}
+			prober = &KubernetesProber{client: clients.kubernetes, config: *probeConfig.Kubernetes, severity: probeConfig.Severity, now: time.Now}
+		default:
+			return nil, fmt.Errorf("probe %q: unsupported type %q", probeConfig.Name, probeConfig.Type)
+		}
+		probes = append(probes, &probe{config: probeConfig, prober: prober, state: newProbeState(probeConfig)})
+	}
+	return probes, nil
+}
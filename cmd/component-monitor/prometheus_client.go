@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	routeclientset "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// defaultPrometheusRoute is the route of the OpenShift cluster monitoring Prometheus.
+const defaultPrometheusRoute = "openshift-monitoring/prometheus-k8s"
+
+// PrometheusClient wraps the Prometheus API client for querying metrics.
+type PrometheusClient struct {
+	api v1.API
+}
+
+// PrometheusConnection describes how to reach and authenticate with a Prometheus compatible API, such as
+// Prometheus itself or a Thanos querier.
+type PrometheusConnection struct {
+	// URL is the address of the API. When empty, the address is discovered from Route.
+	URL string `yaml:"url"`
+	// Route is the namespace/name of the OpenShift route that exposes the API, looked up with the kubeconfig.
+	Route string `yaml:"route"`
+	// BearerTokenFile holds the bearer token to authenticate with, and is re-read when it changes.
+	BearerTokenFile string `yaml:"bearer_token_file"`
+	// Username and PasswordFile authenticate with basic auth.
+	Username     string `yaml:"username"`
+	PasswordFile string `yaml:"password_file"`
+	// CAFile holds the PEM encoded CAs trusted to serve the API, instead of the system ones.
+	CAFile string `yaml:"ca_file"`
+	// InClusterConfig authenticates with the service account of the pod the monitor runs in, and trusts the
+	// cluster CA. BearerTokenFile and CAFile take precedence when set.
+	InClusterConfig bool `yaml:"in_cluster_config"`
+}
+
+// Validate checks that the connection options are consistent.
+func (c PrometheusConnection) Validate() error {
+	if c.URL != "" {
+		if _, err := url.ParseRequestURI(c.URL); err != nil {
+			return fmt.Errorf("invalid Prometheus URL: %w", err)
+		}
+	} else if _, _, err := splitRoute(c.Route); err != nil {
+		return err
+	}
+	if (c.Username == "") != (c.PasswordFile == "") {
+		return errors.New("basic auth needs both a Prometheus username and password file")
+	}
+	if c.Username != "" && (c.BearerTokenFile != "" || c.InClusterConfig) {
+		return errors.New("basic auth cannot be combined with a Prometheus bearer token")
+	}
+	return nil
+}
+
+// splitRoute splits a namespace/name route reference.
+func splitRoute(route string) (string, string, error) {
+	namespace, name, found := strings.Cut(route, "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid Prometheus route %q: must be namespace/name", route)
+	}
+	return namespace, name, nil
+}
+
+// discoverRouteURL returns the URL of the route with the given namespace/name.
+func discoverRouteURL(ctx context.Context, routes routeclientset.RoutesGetter, route string) (string, error) {
+	namespace, name, err := splitRoute(route)
+	if err != nil {
+		return "", err
+	}
+	r, err := routes.Routes(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get route %s: %w", route, err)
+	}
+	if r.Spec.TLS != nil {
+		return "https://" + r.Spec.Host, nil
+	}
+	return "http://" + r.Spec.Host, nil
+}
+
+// roundTripper returns the round tripper that authenticates requests to the API. Without any authentication
+// options, routes are accessed with the bearer token of the kubeconfig.
+func (c PrometheusConnection) roundTripper(kubeconfig *rest.Config) (http.RoundTripper, error) {
+	base := api.DefaultRoundTripper.(*http.Transport).Clone()
+	if c.CAFile != "" {
+		pool, err := loadCAFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	switch {
+	case c.Username != "":
+		password, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %w", err)
+		}
+		return transport.NewBasicAuthRoundTripper(c.Username, strings.TrimSpace(string(password)), base), nil
+	case c.BearerTokenFile != "":
+		return transport.NewBearerAuthWithRefreshRoundTripper("", c.BearerTokenFile, base)
+	case c.URL == "" && kubeconfig != nil && kubeconfig.BearerToken != "":
+		return transport.NewBearerAuthRoundTripper(kubeconfig.BearerToken, base), nil
+	}
+	return base, nil
+}
+
+// NewPrometheusClient creates a Prometheus client for the connection. Route discovery needs a kubeconfig, which
+// is otherwise unused and may be nil.
+func NewPrometheusClient(connection PrometheusConnection, kubeconfig *rest.Config) (*PrometheusClient, error) {
+	if connection.InClusterConfig {
+		inCluster, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load in-cluster config: %w", err)
+		}
+		if connection.BearerTokenFile == "" {
+			connection.BearerTokenFile = inCluster.BearerTokenFile
+		}
+		if connection.CAFile == "" {
+			connection.CAFile = inCluster.TLSClientConfig.CAFile
+		}
+	}
+
+	address := connection.URL
+	if address == "" {
+		if kubeconfig == nil {
+			return nil, errors.New("a kubeconfig is needed to discover the Prometheus route")
+		}
+		routeClient, err := routeclientset.NewForConfig(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		address, err = discoverRouteURL(context.Background(), routeClient, connection.Route)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roundTripper, err := connection.roundTripper(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := api.NewClient(api.Config{
+		Address:      address,
+		RoundTripper: roundTripper,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrometheusClient{
+		api: v1.NewAPI(client),
+	}, nil
+}
+
+// newPrometheusClients creates a client for each Prometheus source that a probe queries, keyed by the name of the
+// source. The Prometheus configured with flags has the empty name and is reached with connection and kubeconfig,
+// which may be nil; loadContext loads the kubeconfig context of a source.
+func newPrometheusClients(config *MonitorConfig, connection PrometheusConnection, kubeconfig *rest.Config, loadContext func(context string) (*rest.Config, error)) (map[string]v1.API, error) {
+	queried := make(map[string]bool)
+	for _, probe := range config.Probes {
+		if probe.Type == ProbeTypePrometheus {
+			queried[probe.Prometheus.Source] = true
+		}
+	}
+
+	clients := make(map[string]v1.API)
+	if queried[""] {
+		client, err := NewPrometheusClient(connection, kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		clients[""] = client.api
+	}
+	for _, source := range config.PrometheusSources {
+		if !queried[source.Name] {
+			continue
+		}
+		var sourceKubeconfig *rest.Config
+		if source.KubeconfigContext != "" {
+			var err error
+			sourceKubeconfig, err = loadContext(source.KubeconfigContext)
+			if err != nil {
+				return nil, fmt.Errorf("prometheus source %q: failed to load kubeconfig context %q: %w", source.Name, source.KubeconfigContext, err)
+			}
+		}
+		client, err := NewPrometheusClient(source.PrometheusConnection, sourceKubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus source %q: %w", source.Name, err)
+		}
+		clients[source.Name] = client.api
+	}
+	return clients, nil
+}
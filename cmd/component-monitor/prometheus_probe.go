@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"ship-status-dash/pkg/types"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+// PrometheusProber evaluates a PromQL query and fails with the severity of the most severe threshold that the
+// result matches.
+type PrometheusProber struct {
+	api        v1.API
+	config     PrometheusProbeConfig
+	thresholds []Threshold
+	now        func() time.Time
+}
+
+// sampleValues returns the values of the samples of an instant query result.
+func sampleValues(result model.Value) ([]float64, error) {
+	switch v := result.(type) {
+	case model.Vector:
+		values := make([]float64, 0, len(v))
+		for _, sample := range v {
+			values = append(values, float64(sample.Value))
+		}
+		return values, nil
+	case *model.Scalar:
+		return []float64{float64(v.Value)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %s", result.Type())
+	}
+}
+
+// stepValues returns the values of a range query result at each step from start to end.
+func stepValues(result model.Value, start, end time.Time, step time.Duration) ([][]float64, error) {
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unsupported result type %s", result.Type())
+	}
+
+	byTime := make(map[model.Time][]float64)
+	for _, series := range matrix {
+		for _, pair := range series.Values {
+			byTime[pair.Timestamp] = append(byTime[pair.Timestamp], float64(pair.Value))
+		}
+	}
+
+	var steps [][]float64
+	for t := start; !t.After(end); t = t.Add(step) {
+		steps = append(steps, byTime[model.TimeFromUnixNano(t.UnixNano())])
+	}
+	return steps, nil
+}
+
+func formatValues(values []float64) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, fmt.Sprintf("%g", value))
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+// observedValue returns the first sample that satisfies the condition, or the first sample when the condition
+// does not compare values. It returns nil when there is no such sample.
+func observedValue(condition Condition, values []float64) *float64 {
+	for _, value := range values {
+		switch condition.Operator {
+		case OperatorAny, OperatorNone:
+		default:
+			if !condition.compare(value) {
+				continue
+			}
+		}
+		return &value
+	}
+	return nil
+}
+
+// describe explains why a threshold matched.
+func (p *PrometheusProber) describe(threshold Threshold) string {
+	switch threshold.Operator {
+	case OperatorAny, OperatorNone:
+		if p.config.For > 0 {
+			return fmt.Sprintf(", continuously for %s", p.config.For)
+		}
+		return ""
+	}
+	if p.config.For > 0 {
+		return fmt.Sprintf(", which has been %s %g for %s", threshold.Operator, threshold.Value, p.config.For)
+	}
+	return fmt.Sprintf(", which is %s %g", threshold.Operator, threshold.Value)
+}
+
+// result builds the probe result from the values at each evaluated step, the last being the most recent. A
+// threshold matches when it matches at every step.
+func (p *PrometheusProber) result(steps [][]float64) ProbeResult {
+	latest := steps[len(steps)-1]
+	result := ProbeResult{
+		Message: fmt.Sprintf("Query %s returned %s", p.config.Query, formatValues(latest)),
+		Value:   observedValue(Condition{Operator: OperatorAny}, latest),
+	}
+
+	var matched *Threshold
+	for i, threshold := range p.thresholds {
+		if matched != nil && types.GetSeverityLevel(threshold.Severity) <= types.GetSeverityLevel(matched.Severity) {
+			continue
+		}
+		matchesThroughout := true
+		for _, values := range steps {
+			if !threshold.matches(values) {
+				matchesThroughout = false
+				break
+			}
+		}
+		if matchesThroughout {
+			matched = &p.thresholds[i]
+		}
+	}
+	if matched == nil {
+		return result
+	}
+
+	result.Severity = matched.Severity
+	result.Message += p.describe(*matched)
+	result.Value = observedValue(matched.Condition, latest)
+	return result
+}
+
+func (p *PrometheusProber) logWarnings(warnings v1.Warnings) {
+	if len(warnings) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"query":    p.config.Query,
+			"warnings": warnings,
+		}).Warn("Query returned warnings")
+	}
+}
+
+// Probe runs the query once, or over the last For with a range query when For is set.
+func (p *PrometheusProber) Probe(ctx context.Context) (ProbeResult, error) {
+	// Whole seconds keep the steps of range queries aligned with the timestamps Prometheus returns
+	now := p.now().Truncate(time.Second)
+
+	if p.config.For > 0 {
+		start := now.Add(-p.config.For)
+		result, warnings, err := p.api.QueryRange(ctx, p.config.Query, v1.Range{Start: start, End: now, Step: p.config.Step})
+		if err != nil {
+			return ProbeResult{}, fmt.Errorf("range query failed: %w", err)
+		}
+		p.logWarnings(warnings)
+
+		steps, err := stepValues(result, start, now, p.config.Step)
+		if err != nil {
+			return ProbeResult{}, err
+		}
+		return p.result(steps), nil
+	}
+
+	result, warnings, err := p.api.Query(ctx, p.config.Query, now)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("query failed: %w", err)
+	}
+	p.logWarnings(warnings)
+
+	values, err := sampleValues(result)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	return p.result([][]float64{values}), nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePrometheusAPI answers instant and range queries with a fixed result.
+type fakePrometheusAPI struct {
+	v1.API
+	result model.Value
+	err    error
+	ranges []v1.Range
+}
+
+func (f *fakePrometheusAPI) Query(ctx context.Context, query string, ts time.Time, opts ...v1.Option) (model.Value, v1.Warnings, error) {
+	return f.result, nil, f.err
+}
+
+func (f *fakePrometheusAPI) QueryRange(ctx context.Context, query string, r v1.Range, opts ...v1.Option) (model.Value, v1.Warnings, error) {
+	f.ranges = append(f.ranges, r)
+	return f.result, nil, f.err
+}
+
+func vector(values ...float64) model.Vector {
+	vector := model.Vector{}
+	for _, value := range values {
+		vector = append(vector, &model.Sample{Metric: model.Metric{"job": "deck"}, Value: model.SampleValue(value)})
+	}
+	return vector
+}
+
+// series returns a range query series with a value for each step starting at start, skipping NaN values.
+func series(start time.Time, step time.Duration, values ...float64) *model.SampleStream {
+	stream := &model.SampleStream{Metric: model.Metric{"job": "deck"}}
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		stream.Values = append(stream.Values, model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(start.Add(time.Duration(i) * step).UnixNano()),
+			Value:     model.SampleValue(value),
+		})
+	}
+	return stream
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestPrometheusProber_Probe(t *testing.T) {
+	thresholds := []Threshold{
+		{Condition: Condition{Operator: OperatorGreater, Value: 0.05}, Severity: types.SeverityDegraded},
+		{Condition: Condition{Operator: OperatorGreater, Value: 0.5}, Severity: types.SeverityDown},
+	}
+
+	tests := []struct {
+		name          string
+		thresholds    []Threshold
+		result        model.Value
+		err           error
+		expected      ProbeResult
+		expectedError string
+	}{
+		{
+			name:       "absent query without samples is healthy",
+			thresholds: []Threshold{{Condition: Condition{Operator: OperatorAny}, Severity: types.SeverityDown}},
+			result:     vector(),
+			expected:   ProbeResult{Message: "Query q returned []"},
+		},
+		{
+			name:       "absent query with a sample is unhealthy",
+			thresholds: []Threshold{{Condition: Condition{Operator: OperatorAny}, Severity: types.SeverityDown}},
+			result:     vector(1),
+			expected:   ProbeResult{Severity: types.SeverityDown, Message: "Query q returned [1]", Value: float64Ptr(1)},
+		},
+		{
+			name:       "below all thresholds",
+			thresholds: thresholds,
+			result:     &model.Scalar{Value: 0.01},
+			expected:   ProbeResult{Message: "Query q returned [0.01]", Value: float64Ptr(0.01)},
+		},
+		{
+			name:       "lower threshold exceeded",
+			thresholds: thresholds,
+			result:     &model.Scalar{Value: 0.2},
+			expected:   ProbeResult{Severity: types.SeverityDegraded, Message: "Query q returned [0.2], which is > 0.05", Value: float64Ptr(0.2)},
+		},
+		{
+			name:       "most severe threshold wins",
+			thresholds: thresholds,
+			result:     vector(0.01, 0.6),
+			expected:   ProbeResult{Severity: types.SeverityDown, Message: "Query q returned [0.01, 0.6], which is > 0.5", Value: float64Ptr(0.6)},
+		},
+		{
+			name:       "most severe threshold wins regardless of order",
+			thresholds: []Threshold{thresholds[1], thresholds[0]},
+			result:     &model.Scalar{Value: 0.6},
+			expected:   ProbeResult{Severity: types.SeverityDown, Message: "Query q returned [0.6], which is > 0.5", Value: float64Ptr(0.6)},
+		},
+		{
+			name:          "query error",
+			thresholds:    thresholds,
+			err:           errors.New("connection refused"),
+			expectedError: "query failed: connection refused",
+		},
+		{
+			name:          "unsupported result type",
+			thresholds:    thresholds,
+			result:        model.Matrix{},
+			expectedError: "unsupported result type matrix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober := &PrometheusProber{
+				api:        &fakePrometheusAPI{result: tt.result, err: tt.err},
+				config:     PrometheusProbeConfig{Query: "q"},
+				thresholds: tt.thresholds,
+				now:        time.Now,
+			}
+
+			result, err := prober.Probe(context.Background())
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPrometheusProber_ProbeFor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	step := time.Minute
+	start := now.Add(-3 * time.Minute)
+	thresholds := []Threshold{
+		{Condition: Condition{Operator: OperatorGreater, Value: 0.05}, Severity: types.SeverityDegraded},
+		{Condition: Condition{Operator: OperatorGreater, Value: 0.5}, Severity: types.SeverityDown},
+	}
+
+	tests := []struct {
+		name          string
+		thresholds    []Threshold
+		result        model.Value
+		expected      ProbeResult
+		expectedError string
+	}{
+		{
+			name:       "transient spike does not match",
+			thresholds: thresholds,
+			result:     model.Matrix{series(start, step, 0.01, 0.9, 0.01, 0.02)},
+			expected:   ProbeResult{Message: "Query q returned [0.02]", Value: float64Ptr(0.02)},
+		},
+		{
+			name:       "sustained breach matches",
+			thresholds: thresholds,
+			result:     model.Matrix{series(start, step, 0.6, 0.9, 0.3, 0.7)},
+			expected:   ProbeResult{Severity: types.SeverityDegraded, Message: "Query q returned [0.7], which has been > 0.05 for 3m0s", Value: float64Ptr(0.7)},
+		},
+		{
+			name:       "breach by different series at each step",
+			thresholds: thresholds,
+			result:     model.Matrix{series(start, step, 0.6, 0.01, 0.6, 0.01), series(start, step, 0.01, 0.6, 0.01, 0.6)},
+			expected:   ProbeResult{Severity: types.SeverityDown, Message: "Query q returned [0.01, 0.6], which has been > 0.5 for 3m0s", Value: float64Ptr(0.6)},
+		},
+		{
+			name:       "samples missing at a step",
+			thresholds: []Threshold{{Condition: Condition{Operator: OperatorAny}, Severity: types.SeverityDown}},
+			result:     model.Matrix{series(start, step, 1, math.NaN(), 1, 1)},
+			expected:   ProbeResult{Message: "Query q returned [1]", Value: float64Ptr(1)},
+		},
+		{
+			name:       "samples present at every step",
+			thresholds: []Threshold{{Condition: Condition{Operator: OperatorAny}, Severity: types.SeverityDown}},
+			result:     model.Matrix{series(start, step, 1, 1, 1, 1)},
+			expected:   ProbeResult{Severity: types.SeverityDown, Message: "Query q returned [1], continuously for 3m0s", Value: float64Ptr(1)},
+		},
+		{
+			name:          "unsupported result type",
+			thresholds:    thresholds,
+			result:        vector(1),
+			expectedError: "unsupported result type vector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakePrometheusAPI{result: tt.result}
+			prober := &PrometheusProber{
+				api:        api,
+				config:     PrometheusProbeConfig{Query: "q", For: 3 * time.Minute, Step: step},
+				thresholds: tt.thresholds,
+				now:        func() time.Time { return now.Add(300 * time.Millisecond) },
+			}
+
+			result, err := prober.Probe(context.Background())
+			require.Len(t, api.ranges, 1)
+			assert.Equal(t, v1.Range{Start: start, End: now, Step: step}, api.ranges[0])
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
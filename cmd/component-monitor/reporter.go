@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ship-status-dash/pkg/types"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// outageReporter turns probe results into dashboard outages. It opens an outage when a probe fails, raises its
+// severity when the probe gets worse, and resolves it when the probe recovers, but only for outages that it opened
+// itself and that are still marked auto-resolvable. The severity is never lowered while the outage is open.
+type outageReporter struct {
+	client dashboardAPI
+	// identity is the user the dashboard authenticates the monitor as. It is used to recognize outages that were
+	// opened before the monitor restarted.
+	identity string
+	now      func() time.Time
+
+	// mu only guards opened, and is never held while calling the dashboard so that slow calls for one probe do
+	// not hold up the others. The scheduler never runs a probe concurrently with itself, so the reports of a
+	// single probe cannot race.
+	mu sync.Mutex
+	// opened maps probe names to the outage each one opened and has not resolved yet.
+	opened map[string]openedOutage
+}
+
+// openedOutage is an outage opened by a probe, with the severity it was last reported with.
+type openedOutage struct {
+	id       uint
+	severity types.Severity
+}
+
+func newOutageReporter(client dashboardAPI, identity string) *outageReporter {
+	return &outageReporter{
+		client:   client,
+		identity: identity,
+		now:      time.Now,
+		opened:   make(map[string]openedOutage),
+	}
+}
+
+// lookup returns the outage the probe opened and has not resolved yet, if any.
+func (r *outageReporter) lookup(p *probe) (openedOutage, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	opened, open := r.opened[p.config.Name]
+	return opened, open
+}
+
+func (r *outageReporter) remember(p *probe, opened openedOutage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opened[p.config.Name] = opened
+}
+
+func (r *outageReporter) forget(p *probe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.opened, p.config.Name)
+}
+
+// outageIsActive reports whether an outage has not ended at now.
+func outageIsActive(outage types.Outage, now time.Time) bool {
+	return !outage.EndTime.Valid || outage.EndTime.Time.After(now)
+}
+
+// isOpenedBy reports whether an active outage was opened by the probe.
+func (r *outageReporter) isOpenedBy(outage types.Outage, p *probe, now time.Time) bool {
+	return outage.DiscoveredFrom == p.config.Name &&
+		outage.CreatedBy == r.identity &&
+		outage.AutoResolve &&
+		outageIsActive(outage, now)
+}
+
+// Recover finds the outages the probes opened before the monitor restarted, so that they are resolved when the
+// probes recover. It does nothing when the monitor identity is unknown.
+func (r *outageReporter) Recover(ctx context.Context, probes []*probe) error {
+	if r.identity == "" {
+		return nil
+	}
+
+	var errs []error
+	now := r.now()
+	for _, p := range probes {
+		outages, err := r.client.GetOutages(ctx, p.config.Component, p.config.SubComponent)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, outage := range outages {
+			if r.isOpenedBy(outage, p, now) {
+				r.remember(p, openedOutage{id: outage.ID, severity: outage.Severity})
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Report opens, escalates or resolves the outage of a probe according to its latest result.
+func (r *outageReporter) Report(ctx context.Context, p *probe, result ProbeResult) {
+	logger := logrus.WithFields(logrus.Fields{
+		"probe":         p.config.Name,
+		"component":     p.config.Component,
+		"sub_component": p.config.SubComponent,
+	})
+
+	opened, open := r.lookup(p)
+	switch {
+	case !result.Healthy() && !open:
+		r.open(ctx, logger, p, result)
+	case !result.Healthy() && types.GetSeverityLevel(result.Severity) > types.GetSeverityLevel(opened.severity):
+		r.escalate(ctx, logger.WithField("outage_id", opened.id), p, opened.id, result.Severity)
+	case result.Healthy() && open:
+		r.resolve(ctx, logger.WithField("outage_id", opened.id), p, opened.id)
+	}
+}
+
+// outageDescription describes the probe result that opened an outage.
+func outageDescription(result ProbeResult) string {
+	if result.Value == nil {
+		return result.Message
+	}
+	return fmt.Sprintf("%s\n\nObserved value: %g", result.Message, *result.Value)
+}
+
+func (r *outageReporter) open(ctx context.Context, logger *logrus.Entry, p *probe, result ProbeResult) {
+	outage, err := r.client.CreateOutage(ctx, p.config.Component, p.config.SubComponent, types.Outage{
+		Severity:       result.Severity,
+		StartTime:      r.now(),
+		Description:    outageDescription(result),
+		DiscoveredFrom: p.config.Name,
+		CreatedBy:      r.identity,
+		AutoResolve:    true,
+	})
+	if err != nil {
+		if errors.Is(err, errActiveOutageExists) {
+			// Someone else's outage already covers the problem; it is theirs to resolve.
+			logger.Debug("Sub-component already has an active outage, not opening another")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to open outage")
+		return
+	}
+
+	r.remember(p, openedOutage{id: outage.ID, severity: outage.Severity})
+	logger.WithField("outage_id", outage.ID).Info("Opened outage")
+}
+
+// ownedOutage retrieves an outage the probe opened, and forgets it unless it still exists and is auto-resolvable.
+func (r *outageReporter) ownedOutage(ctx context.Context, logger *logrus.Entry, p *probe, id uint) (types.Outage, bool) {
+	outage, err := r.client.GetOutage(ctx, p.config.Component, p.config.SubComponent, id)
+	if err != nil {
+		if errors.Is(err, errOutageNotFound) {
+			logger.Info("Outage was deleted, forgetting it")
+			r.forget(p)
+			return outage, false
+		}
+		logger.WithField("error", err).Error("Failed to get outage")
+		return outage, false
+	}
+
+	if !outage.AutoResolve || !outageIsActive(outage, r.now()) {
+		// The outage was taken over or ended by someone else.
+		logger.Info("Outage is no longer auto-resolvable, leaving it alone")
+		r.forget(p)
+		return outage, false
+	}
+	return outage, true
+}
+
+func (r *outageReporter) escalate(ctx context.Context, logger *logrus.Entry, p *probe, id uint, severity types.Severity) {
+	outage, owned := r.ownedOutage(ctx, logger, p, id)
+	if !owned {
+		return
+	}
+	if types.GetSeverityLevel(outage.Severity) >= types.GetSeverityLevel(severity) {
+		// Someone already raised the severity at least as far
+		r.remember(p, openedOutage{id: id, severity: outage.Severity})
+		return
+	}
+
+	if _, err := r.client.UpdateSeverity(ctx, p.config.Component, p.config.SubComponent, id, severity); err != nil {
+		if errors.Is(err, errOutageNotFound) {
+			r.forget(p)
+			return
+		}
+		logger.WithField("error", err).Error("Failed to update outage severity")
+		return
+	}
+
+	r.remember(p, openedOutage{id: id, severity: severity})
+	logger.WithFields(logrus.Fields{"from": outage.Severity, "to": severity}).Info("Raised outage severity")
+}
+
+func (r *outageReporter) resolve(ctx context.Context, logger *logrus.Entry, p *probe, id uint) {
+	if _, owned := r.ownedOutage(ctx, logger, p, id); !owned {
+		return
+	}
+
+	if _, err := r.client.ResolveOutage(ctx, p.config.Component, p.config.SubComponent, id, r.now()); err != nil {
+		if errors.Is(err, errOutageNotFound) {
+			r.forget(p)
+			return
+		}
+		logger.WithField("error", err).Error("Failed to resolve outage")
+		return
+	}
+
+	r.forget(p)
+	logger.Info("Resolved outage")
+}
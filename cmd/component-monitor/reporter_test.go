@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeDashboard keeps outages in memory.
+type fakeDashboard struct {
+	outages  map[uint]*types.Outage
+	nextID   uint
+	created  int
+	resolved []uint
+	updated  []types.Severity
+}
+
+func newFakeDashboard(outages ...types.Outage) *fakeDashboard {
+	f := &fakeDashboard{outages: make(map[uint]*types.Outage), nextID: 100}
+	for _, outage := range outages {
+		outage := outage
+		f.outages[outage.ID] = &outage
+	}
+	return f
+}
+
+func (f *fakeDashboard) CreateOutage(ctx context.Context, component, subComponent string, outage types.Outage) (types.Outage, error) {
+	for _, existing := range f.outages {
+		if existing.ComponentName == subComponent && outageIsActive(*existing, outage.StartTime) {
+			return *existing, errActiveOutageExists
+		}
+	}
+	f.created++
+	f.nextID++
+	outage.ID = f.nextID
+	outage.ComponentName = subComponent
+	f.outages[outage.ID] = &outage
+	return outage, nil
+}
+
+func (f *fakeDashboard) GetOutage(ctx context.Context, component, subComponent string, id uint) (types.Outage, error) {
+	outage, found := f.outages[id]
+	if !found {
+		return types.Outage{}, errOutageNotFound
+	}
+	return *outage, nil
+}
+
+func (f *fakeDashboard) GetOutages(ctx context.Context, component, subComponent string) ([]types.Outage, error) {
+	var outages []types.Outage
+	for _, outage := range f.outages {
+		if outage.ComponentName == subComponent {
+			outages = append(outages, *outage)
+		}
+	}
+	return outages, nil
+}
+
+func (f *fakeDashboard) ResolveOutage(ctx context.Context, component, subComponent string, id uint, endTime time.Time) (types.Outage, error) {
+	outage, found := f.outages[id]
+	if !found {
+		return types.Outage{}, errOutageNotFound
+	}
+	outage.EndTime = sql.NullTime{Time: endTime, Valid: true}
+	f.resolved = append(f.resolved, id)
+	return *outage, nil
+}
+
+func (f *fakeDashboard) UpdateSeverity(ctx context.Context, component, subComponent string, id uint, severity types.Severity) (types.Outage, error) {
+	outage, found := f.outages[id]
+	if !found {
+		return types.Outage{}, errOutageNotFound
+	}
+	outage.Severity = severity
+	f.updated = append(f.updated, severity)
+	return *outage, nil
+}
+
+func TestOutageReporter_Report(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deckProbe := &probe{config: ProbeConfig{Name: "deck-up", Component: "Prow", SubComponent: "Deck"}}
+	failure := ProbeResult{Severity: types.SeverityDown, Message: "Deck is down"}
+	success := ProbeResult{Message: "Deck is up"}
+
+	t.Run("opens an outage on failure and resolves it on recovery", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, failure)
+		reporter.Report(context.Background(), deckProbe, failure)
+		assert.Equal(t, 1, dashboard.created)
+
+		outage := dashboard.outages[101]
+		assert.Equal(t, "deck-up", outage.DiscoveredFrom)
+		assert.Equal(t, "monitor", outage.CreatedBy)
+		assert.Equal(t, "Deck is down", outage.Description)
+		assert.Equal(t, types.SeverityDown, outage.Severity)
+		assert.True(t, outage.AutoResolve)
+
+		reporter.Report(context.Background(), deckProbe, success)
+		assert.Equal(t, []uint{101}, dashboard.resolved)
+		assert.Empty(t, reporter.opened)
+	})
+
+	t.Run("raises the severity of its outage when the probe gets worse", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeveritySuspected, Message: "Deck is flapping"})
+		reporter.Report(context.Background(), deckProbe, failure)
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeverityDegraded, Message: "Deck is slow"})
+		assert.Equal(t, 1, dashboard.created)
+		assert.Equal(t, []types.Severity{types.SeverityDown}, dashboard.updated)
+		assert.Equal(t, types.SeverityDown, dashboard.outages[101].Severity)
+	})
+
+	t.Run("does not lower a severity raised by someone else", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeveritySuspected, Message: "Deck is flapping"})
+		dashboard.outages[101].Severity = types.SeverityDown
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeverityDegraded, Message: "Deck is slow"})
+		assert.Empty(t, dashboard.updated)
+		assert.Equal(t, types.SeverityDown, dashboard.outages[101].Severity)
+	})
+
+	t.Run("does not escalate outages that are no longer auto-resolvable", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeverityDegraded, Message: "Deck is slow"})
+		dashboard.outages[101].AutoResolve = false
+		reporter.Report(context.Background(), deckProbe, failure)
+		assert.Empty(t, dashboard.updated)
+		assert.Empty(t, reporter.opened)
+	})
+
+	t.Run("does not resolve outages it did not open", func(t *testing.T) {
+		dashboard := newFakeDashboard(types.Outage{Model: gorm.Model{ID: 1}, ComponentName: "Deck", StartTime: now.Add(-time.Hour), AutoResolve: true})
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, failure)
+		reporter.Report(context.Background(), deckProbe, success)
+		assert.Equal(t, 0, dashboard.created)
+		assert.Empty(t, dashboard.resolved)
+	})
+
+	t.Run("leaves outages that are no longer auto-resolvable", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, failure)
+		dashboard.outages[101].AutoResolve = false
+		reporter.Report(context.Background(), deckProbe, success)
+		assert.Empty(t, dashboard.resolved)
+		assert.Empty(t, reporter.opened)
+	})
+
+	t.Run("forgets deleted outages", func(t *testing.T) {
+		dashboard := newFakeDashboard()
+		reporter := newOutageReporter(dashboard, "monitor")
+		reporter.now = func() time.Time { return now }
+
+		reporter.Report(context.Background(), deckProbe, failure)
+		delete(dashboard.outages, 101)
+		reporter.Report(context.Background(), deckProbe, success)
+		assert.Empty(t, dashboard.resolved)
+		assert.Empty(t, reporter.opened)
+	})
+}
+
+// blockingDashboard holds up the outages created for one sub-component until released.
+type blockingDashboard struct {
+	*fakeDashboard
+	subComponent string
+	release      chan struct{}
+}
+
+func (b *blockingDashboard) CreateOutage(ctx context.Context, component, subComponent string, outage types.Outage) (types.Outage, error) {
+	if subComponent == b.subComponent {
+		<-b.release
+	}
+	return b.fakeDashboard.CreateOutage(ctx, component, subComponent, outage)
+}
+
+func TestOutageReporter_ReportDoesNotWaitForOtherProbes(t *testing.T) {
+	deckProbe := &probe{config: ProbeConfig{Name: "deck-up", Component: "Prow", SubComponent: "Deck"}}
+	tideProbe := &probe{config: ProbeConfig{Name: "tide-up", Component: "Prow", SubComponent: "Tide"}}
+	dashboard := &blockingDashboard{fakeDashboard: newFakeDashboard(), subComponent: "Deck", release: make(chan struct{})}
+	reporter := newOutageReporter(dashboard, "monitor")
+
+	deckReported := make(chan struct{})
+	go func() {
+		defer close(deckReported)
+		reporter.Report(context.Background(), deckProbe, ProbeResult{Severity: types.SeverityDown, Message: "Deck is down"})
+	}()
+
+	tideReported := make(chan struct{})
+	go func() {
+		defer close(tideReported)
+		reporter.Report(context.Background(), tideProbe, ProbeResult{Severity: types.SeverityDown, Message: "Tide is down"})
+	}()
+	select {
+	case <-tideReported:
+	case <-time.After(time.Second):
+		t.Fatal("reporting a probe waited for the dashboard call of another probe")
+	}
+
+	close(dashboard.release)
+	<-deckReported
+	assert.Len(t, reporter.opened, 2)
+}
+
+func TestOutageReporter_Recover(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deckProbe := &probe{config: ProbeConfig{Name: "deck-up", Component: "Prow", SubComponent: "Deck"}}
+	tideProbe := &probe{config: ProbeConfig{Name: "tide-up", Component: "Prow", SubComponent: "Tide"}}
+	dashboard := newFakeDashboard(
+		types.Outage{Model: gorm.Model{ID: 1}, ComponentName: "Deck", StartTime: now.Add(-time.Hour), DiscoveredFrom: "deck-up", CreatedBy: "monitor", AutoResolve: true},
+		types.Outage{Model: gorm.Model{ID: 2}, ComponentName: "Tide", StartTime: now.Add(-time.Hour), DiscoveredFrom: "tide-up", CreatedBy: "someone", AutoResolve: true},
+	)
+
+	reporter := newOutageReporter(dashboard, "monitor")
+	reporter.now = func() time.Time { return now }
+	assert.NoError(t, reporter.Recover(context.Background(), []*probe{deckProbe, tideProbe}))
+	assert.Equal(t, map[string]openedOutage{"deck-up": {id: 1}}, reporter.opened)
+
+	anonymous := newOutageReporter(dashboard, "")
+	assert.NoError(t, anonymous.Recover(context.Background(), []*probe{deckProbe, tideProbe}))
+	assert.Empty(t, anonymous.opened)
+}
+
+func TestOutageDescription(t *testing.T) {
+	assert.Equal(t, "Deck is down", outageDescription(ProbeResult{Message: "Deck is down"}))
+	assert.Equal(t, "Query q returned [0.6], which is > 0.5\n\nObserved value: 0.6",
+		outageDescription(ProbeResult{Message: "Query q returned [0.6], which is > 0.5", Value: float64Ptr(0.6)}))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Scheduler runs each probe on its own interval, spread out by jitter so that probes with the same interval do not
+// all run at once. At most concurrency probes run at the same time, each bounded by its timeout, and a run is
+// skipped when the previous run of the same probe has not finished.
+type Scheduler struct {
+	probes      []*probe
+	run         func(ctx context.Context, p *probe)
+	concurrency chan struct{}
+	// jitter is the fraction of the interval by which each wait is randomly lengthened or shortened.
+	jitter float64
+	random func() float64
+
+	wg sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that runs probes with run.
+func NewScheduler(probes []*probe, run func(ctx context.Context, p *probe), concurrency int, jitter float64) *Scheduler {
+	return &Scheduler{
+		probes:      probes,
+		run:         run,
+		concurrency: make(chan struct{}, concurrency),
+		jitter:      jitter,
+		random:      rand.Float64,
+	}
+}
+
+// jittered returns d lengthened or shortened by up to the jitter fraction.
+func (s *Scheduler) jittered(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (1 + s.jitter*(2*s.random()-1)))
+}
+
+// Run schedules the probes until the context is done, then waits for the runs in progress to finish. Their
+// contexts are canceled, so they finish promptly.
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, p := range s.probes {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.schedule(ctx, p)
+		}()
+	}
+	s.wg.Wait()
+}
+
+// schedule runs a single probe on its interval until the context is done. The first run is delayed by up to the
+// jitter fraction of the interval.
+func (s *Scheduler) schedule(ctx context.Context, p *probe) {
+	logger := logrus.WithField("probe", p.config.Name)
+	var running atomic.Bool
+
+	timer := time.NewTimer(time.Duration(float64(p.config.Interval) * s.jitter * s.random()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(s.jittered(p.config.Interval))
+
+		if !running.CompareAndSwap(false, true) {
+			logger.Warn("Skipping run because the previous run has not finished")
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer running.Store(false)
+
+			select {
+			case s.concurrency <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-s.concurrency }()
+
+			runCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+			defer cancel()
+			s.run(runCtx, p)
+		}()
+	}
+}
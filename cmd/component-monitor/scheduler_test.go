@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scheduledProbe(name string, interval, timeout time.Duration) *probe {
+	return &probe{config: ProbeConfig{Name: name, Interval: interval, Timeout: timeout}}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Run("runs each probe on its own interval", func(t *testing.T) {
+		var mu sync.Mutex
+		runs := map[string]int{}
+		scheduler := NewScheduler([]*probe{
+			scheduledProbe("fast", 10*time.Millisecond, time.Second),
+			scheduledProbe("slow", 100*time.Millisecond, time.Second),
+		}, func(ctx context.Context, p *probe) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[p.config.Name]++
+		}, 10, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 255*time.Millisecond)
+		defer cancel()
+		scheduler.Run(ctx)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Greater(t, runs["fast"], 5*runs["slow"])
+		assert.Equal(t, 3, runs["slow"])
+	})
+
+	t.Run("caps concurrency", func(t *testing.T) {
+		var current, peak atomic.Int32
+		var probes []*probe
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			probes = append(probes, scheduledProbe(name, 10*time.Millisecond, time.Second))
+		}
+		scheduler := NewScheduler(probes, func(ctx context.Context, p *probe) {
+			n := current.Add(1)
+			defer current.Add(-1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}, 2, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		scheduler.Run(ctx)
+
+		assert.Equal(t, int32(2), peak.Load())
+	})
+
+	t.Run("skips runs that would overlap", func(t *testing.T) {
+		var runs, concurrent, overlapped atomic.Int32
+		scheduler := NewScheduler([]*probe{scheduledProbe("slow", 10*time.Millisecond, time.Second)}, func(ctx context.Context, p *probe) {
+			runs.Add(1)
+			if concurrent.Add(1) > 1 {
+				overlapped.Add(1)
+			}
+			defer concurrent.Add(-1)
+			time.Sleep(45 * time.Millisecond)
+		}, 10, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		scheduler.Run(ctx)
+
+		assert.Zero(t, overlapped.Load())
+		assert.LessOrEqual(t, runs.Load(), int32(3))
+	})
+
+	t.Run("enforces the probe timeout", func(t *testing.T) {
+		var timedOut atomic.Bool
+		scheduler := NewScheduler([]*probe{scheduledProbe("hung", time.Second, 20*time.Millisecond)}, func(ctx context.Context, p *probe) {
+			<-ctx.Done()
+			timedOut.Store(ctx.Err() == context.DeadlineExceeded)
+		}, 10, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		scheduler.Run(ctx)
+
+		assert.True(t, timedOut.Load())
+	})
+
+	t.Run("reports probes that time out as unhealthy", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		config := ProbeConfig{
+			Name: "hung", Type: ProbeTypeHTTP, Interval: time.Second, Timeout: 20 * time.Millisecond, Severity: types.SeverityDegraded,
+			FailureThreshold: 1, SuccessThreshold: 1,
+			HTTP: &HTTPProbeConfig{URL: server.URL, Method: http.MethodGet, ExpectedStatusCodes: []int{http.StatusOK}, Timeout: time.Second},
+		}
+		prober, err := newHTTPProber(*config.HTTP, config.Severity)
+		require.NoError(t, err)
+		p := &probe{config: config, prober: prober, state: newProbeState(config)}
+
+		scheduler := NewScheduler([]*probe{p}, func(ctx context.Context, p *probe) {
+			runProbe(ctx, p, nil)
+		}, 10, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		scheduler.Run(ctx)
+
+		assert.True(t, p.state.unhealthy)
+		assert.Equal(t, ProbeResult{Severity: types.SeverityDegraded, Message: "Probe timed out after 20ms"}, p.state.unhealthyResult)
+	})
+
+	t.Run("stops runs in progress on shutdown", func(t *testing.T) {
+		var canceled atomic.Bool
+		scheduler := NewScheduler([]*probe{scheduledProbe("hung", time.Second, time.Hour)}, func(ctx context.Context, p *probe) {
+			<-ctx.Done()
+			canceled.Store(ctx.Err() == context.Canceled)
+		}, 10, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+		start := time.Now()
+		scheduler.Run(ctx)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.True(t, canceled.Load())
+	})
+}
+
+func TestScheduler_Jittered(t *testing.T) {
+	scheduler := NewScheduler(nil, nil, 1, 0.1)
+	for _, tt := range []struct {
+		random   float64
+		expected time.Duration
+	}{
+		{random: 0, expected: 27 * time.Second},
+		{random: 0.5, expected: 30 * time.Second},
+		{random: 1, expected: 33 * time.Second},
+	} {
+		scheduler.random = func() float64 { return tt.random }
+		assert.Equal(t, tt.expected, scheduler.jittered(30*time.Second))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"ship-status-dash/pkg/types"
+	"time"
+)
+
+// probeState smooths the results of a probe before they are reported, so that a probe that briefly fails or
+// keeps changing between healthy and unhealthy does not open and resolve a stream of outages. It is only used by
+// the single goroutine running its probe.
+type probeState struct {
+	config ProbeConfig
+	now    func() time.Time
+
+	// unhealthy is whether the probe is considered unhealthy, in which case unhealthyResult is what it is
+	// reported as and unhealthySince is when that started.
+	unhealthy       bool
+	unhealthyResult ProbeResult
+	unhealthySince  time.Time
+
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	// lastHealthy is the health of the previous result, or nil before the first one.
+	lastHealthy *bool
+	// transitions are the times the result changed between healthy and unhealthy within the flapping window.
+	transitions []time.Time
+}
+
+func newProbeState(config ProbeConfig) *probeState {
+	return &probeState{config: config, now: time.Now}
+}
+
+// flapping records a change of health and reports whether the probe changed often enough to be flapping.
+func (s *probeState) flapping(healthy bool, now time.Time) bool {
+	changed := s.lastHealthy != nil && *s.lastHealthy != healthy
+	s.lastHealthy = &healthy
+	if s.config.Flapping == nil {
+		return false
+	}
+
+	if changed {
+		s.transitions = append(s.transitions, now)
+	}
+	cutoff := now.Add(-s.config.Flapping.Window)
+	for len(s.transitions) > 0 && !s.transitions[0].After(cutoff) {
+		s.transitions = s.transitions[1:]
+	}
+	return len(s.transitions) >= s.config.Flapping.Transitions
+}
+
+func (s *probeState) becomeUnhealthy(result ProbeResult, now time.Time) ProbeResult {
+	if !s.unhealthy {
+		s.unhealthy = true
+		s.unhealthySince = now
+	}
+	s.unhealthyResult = result
+	return result
+}
+
+// Observe records a probe result and returns the result to report, which is unhealthy once enough consecutive
+// failures were seen and stays unhealthy until enough consecutive successes were seen and the minimum outage
+// duration has passed. While the probe is flapping it is reported as a single Suspected outage.
+func (s *probeState) Observe(result ProbeResult) ProbeResult {
+	now := s.now()
+	healthy := result.Healthy()
+	if healthy {
+		s.consecutiveSuccesses++
+		s.consecutiveFailures = 0
+	} else {
+		s.consecutiveFailures++
+		s.consecutiveSuccesses = 0
+	}
+
+	if s.flapping(healthy, now) {
+		if s.unhealthy {
+			// An outage is already open and covers the flapping
+			return s.unhealthyResult
+		}
+		return s.becomeUnhealthy(ProbeResult{
+			Severity: types.SeveritySuspected,
+			Message: fmt.Sprintf("Probe is flapping: its result changed %d times in the last %s. Latest result: %s",
+				len(s.transitions), s.config.Flapping.Window, result.Message),
+			Value: result.Value,
+		}, now)
+	}
+
+	if !s.unhealthy {
+		if !healthy && s.consecutiveFailures >= s.config.FailureThreshold {
+			return s.becomeUnhealthy(result, now)
+		}
+		if !healthy {
+			return ProbeResult{Message: fmt.Sprintf("%s (failure %d of %d before opening an outage)", result.Message, s.consecutiveFailures, s.config.FailureThreshold), Value: result.Value}
+		}
+		return result
+	}
+
+	if !healthy {
+		return s.becomeUnhealthy(result, now)
+	}
+	if s.consecutiveSuccesses >= s.config.SuccessThreshold && now.Sub(s.unhealthySince) >= s.config.MinOutageDuration {
+		s.unhealthy = false
+		return result
+	}
+	return s.unhealthyResult
+}
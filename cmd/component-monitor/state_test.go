@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestProbeState_Observe(t *testing.T) {
+	down := ProbeResult{Severity: types.SeverityDown, Message: "down"}
+	up := ProbeResult{Message: "up"}
+
+	// step is a probe result, the time since the previous one, and the severity expected to be reported for it
+	type step struct {
+		after    time.Duration
+		result   ProbeResult
+		reported types.Severity
+	}
+
+	tests := []struct {
+		name   string
+		config ProbeConfig
+		steps  []step
+	}{
+		{
+			name:   "defaults follow every result",
+			config: ProbeConfig{FailureThreshold: 1, SuccessThreshold: 1},
+			steps: []step{
+				{result: down, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+			},
+		},
+		{
+			name:   "consecutive failures are needed to open an outage",
+			config: ProbeConfig{FailureThreshold: 3, SuccessThreshold: 1},
+			steps: []step{
+				{result: down},
+				{after: 30 * time.Second, result: down},
+				{after: 30 * time.Second, result: up},
+				{after: 30 * time.Second, result: down},
+				{after: 30 * time.Second, result: down},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+			},
+		},
+		{
+			name:   "consecutive successes are needed to resolve an outage",
+			config: ProbeConfig{FailureThreshold: 1, SuccessThreshold: 2},
+			steps: []step{
+				{result: down, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up},
+			},
+		},
+		{
+			name:   "outages stay open for the minimum duration",
+			config: ProbeConfig{FailureThreshold: 1, SuccessThreshold: 1, MinOutageDuration: 5 * time.Minute},
+			steps: []step{
+				{result: down, reported: types.SeverityDown},
+				{after: time.Minute, result: up, reported: types.SeverityDown},
+				{after: 3 * time.Minute, result: up, reported: types.SeverityDown},
+				{after: time.Minute, result: up},
+			},
+		},
+		{
+			name:   "a worse result while unhealthy is reported",
+			config: ProbeConfig{FailureThreshold: 1, SuccessThreshold: 1},
+			steps: []step{
+				{result: ProbeResult{Severity: types.SeverityDegraded, Message: "slow"}, reported: types.SeverityDegraded},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+			},
+		},
+		{
+			name: "flapping escalates to a single Suspected outage",
+			config: ProbeConfig{FailureThreshold: 2, SuccessThreshold: 2,
+				Flapping: &FlapDetection{Transitions: 3, Window: 10 * time.Minute}},
+			steps: []step{
+				{result: down},
+				{after: 30 * time.Second, result: up},
+				{after: 30 * time.Second, result: down},
+				{after: 30 * time.Second, result: up, reported: types.SeveritySuspected},
+				{after: 30 * time.Second, result: down, reported: types.SeveritySuspected},
+				{after: 30 * time.Second, result: up, reported: types.SeveritySuspected},
+				{after: 30 * time.Second, result: up, reported: types.SeveritySuspected},
+				// The transitions age out of the window, after which two successes resolve the outage
+				{after: 10 * time.Minute, result: up},
+			},
+		},
+		{
+			name: "flapping while an outage is open keeps that outage",
+			config: ProbeConfig{FailureThreshold: 1, SuccessThreshold: 2,
+				Flapping: &FlapDetection{Transitions: 2, Window: 10 * time.Minute}},
+			steps: []step{
+				{result: down, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: up, reported: types.SeverityDown},
+				{after: 30 * time.Second, result: down, reported: types.SeverityDown},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+			state := newProbeState(tt.config)
+			state.now = clock.Now
+
+			for i, step := range tt.steps {
+				clock.Advance(step.after)
+				reported := state.Observe(step.result)
+				assert.Equal(t, step.reported, reported.Severity, "step %d", i)
+			}
+		})
+	}
+}
+
+func TestProbeState_ObserveMessages(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	state := newProbeState(ProbeConfig{FailureThreshold: 2, SuccessThreshold: 1, Flapping: &FlapDetection{Transitions: 2, Window: 5 * time.Minute}})
+	state.now = clock.Now
+
+	assert.Equal(t, "down (failure 1 of 2 before opening an outage)", state.Observe(ProbeResult{Severity: types.SeverityDown, Message: "down"}).Message)
+	clock.Advance(time.Minute)
+	state.Observe(ProbeResult{Message: "up"})
+	clock.Advance(time.Minute)
+	assert.Equal(t, "Probe is flapping: its result changed 2 times in the last 5m0s. Latest result: down",
+		state.Observe(ProbeResult{Severity: types.SeverityDown, Message: "down"}).Message)
+}
+
+func TestProbeState_TransitionsWithoutFlapDetection(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	state := newProbeState(ProbeConfig{FailureThreshold: 1, SuccessThreshold: 1})
+	state.now = clock.Now
+
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Minute)
+		state.Observe(ProbeResult{Severity: types.SeverityDown, Message: "down"})
+		clock.Advance(time.Minute)
+		state.Observe(ProbeResult{Message: "up"})
+	}
+	assert.Empty(t, state.transitions)
+}
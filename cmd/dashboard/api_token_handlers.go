@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CreateAPITokenRequest represents the body of a request to create a personal API token.
+type CreateAPITokenRequest struct {
+	Name       string     `json:"name"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Components []string   `json:"components,omitempty"`
+}
+
+// CreateAPITokenResponse contains the newly created token. The token itself is never retrievable again.
+type CreateAPITokenResponse struct {
+	Token    string         `json:"token"`
+	APIToken types.APIToken `json:"api_token"`
+}
+
+// apiTokenHasRights reports whether a token of the user, scoped to the components, would be allowed to do more than
+// view. Tokens act without groups, so users whose roles all come from group-based owners or bindings get nothing
+// from a token.
+func (h *Handlers) apiTokenHasRights(user string, components []string) bool {
+	identity := &Identity{User: user, Method: AuthMethodAPIToken, Components: components}
+	if h.can(identity, nil, ActionReportSuspectedOutage) {
+		return true
+	}
+	for i := range h.config.Components {
+		if h.can(identity, &h.config.Components[i], ActionReportSuspectedOutage) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handlers) validateAPITokenRequest(req *CreateAPITokenRequest, user string, now time.Time) (string, bool) {
+	if req.Name == "" {
+		return "Name is required", false
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return "ExpiresAt must be in the future", false
+		}
+		if req.ExpiresAt.Sub(now) > maxAPITokenLifetime {
+			return fmt.Sprintf("ExpiresAt cannot be more than %d days in the future", int(maxAPITokenLifetime.Hours()/24)), false
+		}
+	}
+	for _, componentName := range req.Components {
+		if h.getComponent(componentName) == nil {
+			return fmt.Sprintf("Component not found: %s", componentName), false
+		}
+	}
+	if !h.apiTokenHasRights(user, req.Components) {
+		return fmt.Sprintf("API tokens do not carry group memberships, so a token of user %q would only have the viewer role; "+
+			"creating one requires a role bound to the user directly", user), false
+	}
+	return "", true
+}
+
+// CreateAPITokenJSON creates a personal API token for the authenticated user. Since tokens only hold the roles bound to
+// the user directly, creating a token that would be limited to viewing is rejected.
+func (h *Handlers) CreateAPITokenJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageAPITokens) {
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	if identity.Method == AuthMethodAPIToken {
+		respondWithError(w, http.StatusForbidden, "API tokens cannot be used to create other API tokens")
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	now := time.Now()
+	if message, valid := h.validateAPITokenRequest(&req, identity.User, now); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"user":       identity.User,
+		"token_name": req.Name,
+	})
+
+	token, err := generateAPIToken()
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to generate API token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	apiToken := types.APIToken{
+		Name:       req.Name,
+		User:       identity.User,
+		TokenHash:  hashAPIToken(token),
+		ExpiresAt:  now.Add(defaultAPITokenLifetime),
+		Components: req.Components,
+	}
+	if req.ExpiresAt != nil {
+		apiToken.ExpiresAt = *req.ExpiresAt
+	}
+
+	if err := h.db.Create(&apiToken).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to create API token in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	logger.Infof("Successfully created API token: %d", apiToken.ID)
+	respondWithJSON(w, http.StatusCreated, CreateAPITokenResponse{Token: token, APIToken: apiToken})
+}
+
+// GetAPITokensJSON lists the active API tokens of the authenticated user.
+func (h *Handlers) GetAPITokensJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageAPITokens) {
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+
+	var apiTokens []types.APIToken
+	if err := h.db.Where("user_name = ?", identity.User).Order("created_at DESC").Find(&apiTokens).Error; err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user":  identity.User,
+			"error": err,
+		}).Error("Failed to query API tokens from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get API tokens")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, apiTokens)
+}
+
+// RevokeAPIToken revokes one of the authenticated user's API tokens.
+func (h *Handlers) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageAPITokens) {
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	tokenID := mux.Vars(r)["tokenId"]
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"user":     identity.User,
+		"token_id": tokenID,
+	})
+
+	var apiToken types.APIToken
+	if err := h.db.Where("id = ? AND user_name = ?", tokenID, identity.User).First(&apiToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query API token from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get API token")
+		return
+	}
+
+	if err := h.db.Delete(&apiToken).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to revoke API token")
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke API token")
+		return
+	}
+
+	logger.Info("Successfully revoked API token")
+	w.WriteHeader(http.StatusNoContent)
+}
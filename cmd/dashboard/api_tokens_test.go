@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+	first, err := generateAPIToken()
+	require.NoError(t, err)
+	second, err := generateAPIToken()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, apiTokenPrefix))
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, hashAPIToken(first), hashAPIToken(first))
+	assert.NotEqual(t, hashAPIToken(first), hashAPIToken(second))
+	assert.NotContains(t, hashAPIToken(first), strings.TrimPrefix(first, apiTokenPrefix))
+}
+
+func TestAPITokenAuthenticator_IgnoresOtherCredentials(t *testing.T) {
+	// No database is configured, so reaching a lookup would panic
+	authenticator := NewAPITokenAuthenticator(nil)
+
+	for _, authorization := range []string{"", "Bearer kubernetes-service-account-token", "Basic dXNlcjpwYXNz"} {
+		req := httptest.NewRequest("GET", "/api/tokens", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		identity, err := authenticator.Authenticate(req)
+		assert.NoError(t, err)
+		assert.Nil(t, identity)
+	}
+}
+
+func TestValidateAPITokenRequest(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	handlers := &Handlers{
+		config: &types.Config{
+			Components: []types.Component{
+				{Name: "Prow", Owners: []types.Owner{{RoverGroup: "dptp"}}},
+				{Name: "Build Farm", RoleBindings: []types.RoleBinding{{Role: types.RoleOwner, Users: []string{"builder"}}}},
+			},
+			RoleBindings: []types.RoleBinding{{Role: types.RoleReporter, Users: []string{"jdoe"}}},
+		},
+	}
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name            string
+		user            string
+		request         CreateAPITokenRequest
+		expectedMessage string
+	}{
+		{
+			name:    "name only uses the default lifetime",
+			request: CreateAPITokenRequest{Name: "ci"},
+		},
+		{
+			name:    "scoped to an existing component with explicit expiry",
+			request: CreateAPITokenRequest{Name: "ci", ExpiresAt: timePtr(now.Add(24 * time.Hour)), Components: []string{"Prow"}},
+		},
+		{
+			name:            "missing name",
+			request:         CreateAPITokenRequest{},
+			expectedMessage: "Name is required",
+		},
+		{
+			name:            "expiry in the past",
+			request:         CreateAPITokenRequest{Name: "ci", ExpiresAt: timePtr(now.Add(-time.Hour))},
+			expectedMessage: "ExpiresAt must be in the future",
+		},
+		{
+			name:            "expiry beyond the maximum lifetime",
+			request:         CreateAPITokenRequest{Name: "ci", ExpiresAt: timePtr(now.Add(maxAPITokenLifetime + time.Hour))},
+			expectedMessage: "ExpiresAt cannot be more than 365 days in the future",
+		},
+		{
+			name:            "unknown component",
+			request:         CreateAPITokenRequest{Name: "ci", Components: []string{"Nope"}},
+			expectedMessage: "Component not found: Nope",
+		},
+		{
+			name:    "user with a role on a single component",
+			user:    "builder",
+			request: CreateAPITokenRequest{Name: "ci", Components: []string{"Build Farm"}},
+		},
+		{
+			name:            "user whose roles come from groups only",
+			user:            "group-member",
+			request:         CreateAPITokenRequest{Name: "ci"},
+			expectedMessage: `API tokens do not carry group memberships, so a token of user "group-member" would only have the viewer role; creating one requires a role bound to the user directly`,
+		},
+		{
+			name:            "scoped to a component the user has no role on",
+			user:            "builder",
+			request:         CreateAPITokenRequest{Name: "ci", Components: []string{"Prow"}},
+			expectedMessage: `API tokens do not carry group memberships, so a token of user "builder" would only have the viewer role; creating one requires a role bound to the user directly`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			if user == "" {
+				user = "jdoe"
+			}
+			message, valid := handlers.validateAPITokenRequest(&tt.request, user, now)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+			assert.Equal(t, tt.expectedMessage, message)
+		})
+	}
+}
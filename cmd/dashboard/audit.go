@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"ship-status-dash/pkg/types"
+
+	"gorm.io/gorm"
+)
+
+// auditLogLockID is the Postgres advisory lock held while appending to the audit log, so that concurrent
+// requests, including those served by other replicas, cannot fork the hash chain.
+const auditLogLockID = 7361746
+
+type auditAppender interface {
+	Append(entry *types.AuditLogEntry) error
+}
+
+// AuditLog appends entries to the hash-chained audit log table.
+type AuditLog struct {
+	db *gorm.DB
+}
+
+// NewAuditLog creates a new AuditLog backed by the given database.
+func NewAuditLog(db *gorm.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// Append chains the entry to the most recent one and stores it.
+func (a *AuditLog) Append(entry *types.AuditLogEntry) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLogLockID).Error; err != nil {
+			return err
+		}
+
+		var previous types.AuditLogEntry
+		if err := tx.Order("id DESC").Limit(1).Find(&previous).Error; err != nil {
+			return err
+		}
+
+		entry.PrevHash = previous.Hash
+		entry.Hash = entry.ComputeHash()
+		return tx.Create(entry).Error
+	})
+}
+
+// verifyAuditChain checks that every entry matches its stored hash and links to its predecessor, starting from
+// prevHash. It returns the hash of the last entry, and the index of the first entry that breaks the chain or -1.
+func verifyAuditChain(prevHash string, entries []types.AuditLogEntry) (string, int) {
+	for i, entry := range entries {
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			return prevHash, i
+		}
+		prevHash = entry.Hash
+	}
+	return prevHash, -1
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// auditState is shared through the request context so that the audit middleware, which runs outside of
+// authentication, learns who the caller was.
+type auditState struct {
+	identity *Identity
+}
+
+type auditStateKey struct{}
+
+func withAuditState(ctx context.Context, state *auditState) context.Context {
+	return context.WithValue(ctx, auditStateKey{}, state)
+}
+
+func recordAuditIdentity(ctx context.Context, identity *Identity) {
+	if state, ok := ctx.Value(auditStateKey{}).(*auditState); ok {
+		state.identity = identity
+	}
+}
+
+// statusRecorder captures the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"ship-status-dash/pkg/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errAuditChainBroken stops the verification early once a broken link has been found.
+var errAuditChainBroken = errors.New("audit log hash chain is broken")
+
+const (
+	defaultAuditLogLimit = 100
+	maxAuditLogLimit     = 1000
+	auditVerifyBatchSize = 1000
+)
+
+// auditLogQuery holds the filters accepted by the audit log endpoint.
+type auditLogQuery struct {
+	User     string
+	Method   string
+	Route    string
+	Path     string
+	Since    *time.Time
+	Until    *time.Time
+	BeforeID uint
+	Limit    int
+}
+
+func parseAuditLogQuery(values url.Values) (auditLogQuery, string, bool) {
+	query := auditLogQuery{
+		User:   values.Get("user"),
+		Method: strings.ToUpper(values.Get("method")),
+		Route:  values.Get("route"),
+		Path:   values.Get("path"),
+		Limit:  defaultAuditLogLimit,
+	}
+
+	for name, target := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		if raw := values.Get(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return query, "Invalid " + name + ": must be an RFC3339 timestamp", false
+			}
+			*target = &parsed
+		}
+	}
+
+	if raw := values.Get("before_id"); raw != "" {
+		beforeID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return query, "Invalid before_id", false
+		}
+		query.BeforeID = uint(beforeID)
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAuditLogLimit {
+			return query, "Invalid limit: must be between 1 and " + strconv.Itoa(maxAuditLogLimit), false
+		}
+		query.Limit = limit
+	}
+
+	return query, "", true
+}
+
+func (q auditLogQuery) apply(db *gorm.DB) *gorm.DB {
+	if q.User != "" {
+		db = db.Where("user_name = ?", q.User)
+	}
+	if q.Method != "" {
+		db = db.Where("method = ?", q.Method)
+	}
+	if q.Route != "" {
+		db = db.Where("route = ?", q.Route)
+	}
+	if q.Path != "" {
+		db = db.Where("path = ?", q.Path)
+	}
+	if q.Since != nil {
+		db = db.Where("timestamp >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		db = db.Where("timestamp < ?", *q.Until)
+	}
+	if q.BeforeID != 0 {
+		db = db.Where("id < ?", q.BeforeID)
+	}
+	return db.Order("id DESC").Limit(q.Limit)
+}
+
+// GetAuditLogJSON lists audit log entries, newest first. Older pages are fetched by passing the ID of the
+// last entry received as before_id.
+func (h *Handlers) GetAuditLogJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewAuditLog) {
+		return
+	}
+
+	query, message, valid := parseAuditLogQuery(r.URL.Query())
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	var entries []types.AuditLogEntry
+	if err := query.apply(h.db).Find(&entries).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to query audit log from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get audit log")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+// AuditLogVerification reports the result of verifying the audit log hash chain.
+type AuditLogVerification struct {
+	Valid          bool  `json:"valid"`
+	EntriesChecked int   `json:"entries_checked"`
+	FirstInvalidID *uint `json:"first_invalid_id,omitempty"`
+}
+
+// VerifyAuditLogJSON walks the whole audit log and checks that no entry has been altered or removed.
+func (h *Handlers) VerifyAuditLogJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewAuditLog) {
+		return
+	}
+
+	result := AuditLogVerification{Valid: true}
+	prevHash := ""
+	var entries []types.AuditLogEntry
+	err := h.db.Order("id").FindInBatches(&entries, auditVerifyBatchSize, func(tx *gorm.DB, batch int) error {
+		var invalid int
+		prevHash, invalid = verifyAuditChain(prevHash, entries)
+		if invalid >= 0 {
+			result.Valid = false
+			result.EntriesChecked += invalid
+			result.FirstInvalidID = &entries[invalid].ID
+			return errAuditChainBroken
+		}
+		result.EntriesChecked += len(entries)
+		return nil
+	}).Error
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		h.logger.WithField("error", err).Error("Failed to query audit log from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to verify audit log")
+		return
+	}
+
+	if !result.Valid {
+		h.logger.WithField("first_invalid_id", *result.FirstInvalidID).Error("Audit log hash chain is broken")
+	}
+	respondWithJSON(w, http.StatusOK, result)
+}
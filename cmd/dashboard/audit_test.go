@@ -0,0 +1,280 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuditLog struct {
+	entries []*types.AuditLogEntry
+}
+
+func (f *fakeAuditLog) Append(entry *types.AuditLogEntry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	config := &types.Config{
+		DefaultRole: types.RoleViewer,
+		Components: []types.Component{{
+			Name:          "Prow",
+			Subcomponents: []types.SubComponent{{Name: "Tide"}},
+			Owners:        []types.Owner{{RoverGroup: "dptp"}},
+		}},
+	}
+	proxyAuthenticator, err := NewProxyAuthenticator([]string{"192.0.2.1"}, "X-Forwarded-User", "X-Forwarded-Groups", "X-Forwarded-Email")
+	require.NoError(t, err)
+
+	outageBody := `{"severity":"Down","start_time":"2025-01-01T00:00:00Z","discovered_from":"test"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		remoteAddr     string
+		user           string
+		expectedStatus int
+		expectedEntry  *types.AuditLogEntry
+	}{
+		{
+			name:           "reads are not audited",
+			method:         http.MethodGet,
+			path:           "/api/status/Nope",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "anonymous mutation",
+			method:         http.MethodPost,
+			path:           "/api/components/Prow/Tide/outages",
+			body:           outageBody,
+			expectedStatus: http.StatusUnauthorized,
+			expectedEntry: &types.AuditLogEntry{
+				Method:     http.MethodPost,
+				Route:      "/api/components/{componentName}/{subComponentName}/outages",
+				Path:       "/api/components/Prow/Tide/outages",
+				SourceIP:   "192.0.2.1",
+				BodyHash:   hashBody([]byte(outageBody)),
+				StatusCode: http.StatusUnauthorized,
+			},
+		},
+		{
+			name:           "authenticated mutation reaches the handler with the body intact",
+			method:         http.MethodPost,
+			path:           "/api/components/Prow/Tide/outages",
+			body:           outageBody,
+			user:           "jdoe",
+			expectedStatus: http.StatusForbidden,
+			expectedEntry: &types.AuditLogEntry{
+				Method:     http.MethodPost,
+				Route:      "/api/components/{componentName}/{subComponentName}/outages",
+				Path:       "/api/components/Prow/Tide/outages",
+				User:       "jdoe",
+				AuthMethod: string(AuthMethodProxy),
+				SourceIP:   "192.0.2.1",
+				BodyHash:   hashBody([]byte(outageBody)),
+				StatusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name:           "oversized body is rejected and audited",
+			method:         http.MethodPost,
+			path:           "/api/components/Prow/Tide/outages",
+			body:           strings.Repeat("a", maxRequestBodySize+1),
+			user:           "jdoe",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedEntry: &types.AuditLogEntry{
+				Method:     http.MethodPost,
+				Route:      "/api/components/{componentName}/{subComponentName}/outages",
+				Path:       "/api/components/Prow/Tide/outages",
+				SourceIP:   "192.0.2.1",
+				BodyHash:   hashBody(nil),
+				StatusCode: http.StatusRequestEntityTooLarge,
+			},
+		},
+		{
+			name:           "rejected credentials are audited",
+			method:         http.MethodDelete,
+			path:           "/api/components/Prow/Tide/outages/1",
+			remoteAddr:     "203.0.113.5:4321",
+			user:           "jdoe",
+			expectedStatus: http.StatusUnauthorized,
+			expectedEntry: &types.AuditLogEntry{
+				Method:     http.MethodDelete,
+				Route:      "/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}",
+				Path:       "/api/components/Prow/Tide/outages/1",
+				SourceIP:   "203.0.113.5",
+				BodyHash:   hashBody(nil),
+				StatusCode: http.StatusUnauthorized,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := &fakeAuditLog{}
+			server := &Server{
+				logger:         logrus.New(),
+				config:         config,
+				handlers:       NewHandlers(logrus.New(), config, nil),
+				corsOrigin:     "*",
+				authenticators: []Authenticator{proxyAuthenticator},
+				auditLog:       auditLog,
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			if tt.user != "" {
+				req.Header.Set("X-Forwarded-User", tt.user)
+			}
+			recorder := httptest.NewRecorder()
+			server.setupRoutes().ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedEntry == nil {
+				assert.Empty(t, auditLog.entries)
+				return
+			}
+			require.Len(t, auditLog.entries, 1)
+			entry := auditLog.entries[0]
+			assert.False(t, entry.Timestamp.IsZero())
+			entry.Timestamp = time.Time{}
+			assert.Equal(t, tt.expectedEntry, entry)
+		})
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	newChain := func() []types.AuditLogEntry {
+		var entries []types.AuditLogEntry
+		prevHash := ""
+		for i, user := range []string{"jdoe", "admin", "jdoe"} {
+			entry := types.AuditLogEntry{
+				ID:         uint(i + 1),
+				Timestamp:  time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+				Method:     http.MethodPost,
+				Path:       "/api/components/Prow/Tide/outages",
+				User:       user,
+				StatusCode: http.StatusCreated,
+				PrevHash:   prevHash,
+			}
+			entry.Hash = entry.ComputeHash()
+			prevHash = entry.Hash
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name            string
+		tamper          func([]types.AuditLogEntry) []types.AuditLogEntry
+		expectedInvalid int
+	}{
+		{
+			name:            "intact chain",
+			tamper:          func(entries []types.AuditLogEntry) []types.AuditLogEntry { return entries },
+			expectedInvalid: -1,
+		},
+		{
+			name: "modified entry",
+			tamper: func(entries []types.AuditLogEntry) []types.AuditLogEntry {
+				entries[1].User = "someone-else"
+				return entries
+			},
+			expectedInvalid: 1,
+		},
+		{
+			name: "modified entry with recomputed hash",
+			tamper: func(entries []types.AuditLogEntry) []types.AuditLogEntry {
+				entries[1].StatusCode = http.StatusForbidden
+				entries[1].Hash = entries[1].ComputeHash()
+				return entries
+			},
+			expectedInvalid: 2,
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []types.AuditLogEntry) []types.AuditLogEntry {
+				return append(entries[:1], entries[2:]...)
+			},
+			expectedInvalid: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, invalid := verifyAuditChain("", tt.tamper(newChain()))
+			assert.Equal(t, tt.expectedInvalid, invalid)
+		})
+	}
+}
+
+func TestParseAuditLogQuery(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		values          url.Values
+		expected        auditLogQuery
+		expectedMessage string
+	}{
+		{
+			name:     "defaults",
+			values:   url.Values{},
+			expected: auditLogQuery{Limit: defaultAuditLogLimit},
+		},
+		{
+			name: "all filters",
+			values: url.Values{
+				"user":      {"jdoe"},
+				"method":    {"delete"},
+				"path":      {"/api/components/Prow/Tide/outages/1"},
+				"since":     {"2025-01-01T00:00:00Z"},
+				"before_id": {"42"},
+				"limit":     {"10"},
+			},
+			expected: auditLogQuery{
+				User:     "jdoe",
+				Method:   http.MethodDelete,
+				Path:     "/api/components/Prow/Tide/outages/1",
+				Since:    &since,
+				BeforeID: 42,
+				Limit:    10,
+			},
+		},
+		{
+			name:            "invalid timestamp",
+			values:          url.Values{"until": {"yesterday"}},
+			expectedMessage: "Invalid until: must be an RFC3339 timestamp",
+		},
+		{
+			name:            "limit too large",
+			values:          url.Values{"limit": {"5000"}},
+			expectedMessage: "Invalid limit: must be between 1 and 1000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, message, valid := parseAuditLogQuery(tt.values)
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+			if valid {
+				assert.Equal(t, tt.expected, query)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"strings"
+)
+
+// AuthMethod identifies how the caller of a request was authenticated.
+type AuthMethod string
+
+const (
+	AuthMethodProxy       AuthMethod = "proxy"
+	AuthMethodTokenReview AuthMethod = "token_review"
+	AuthMethodAPIToken    AuthMethod = "api_token"
+)
+
+// Identity describes the authenticated caller of an API request.
+type Identity struct {
+	User   string     `json:"user"`
+	Email  string     `json:"email,omitempty"`
+	Groups []string   `json:"groups,omitempty"`
+	Method AuthMethod `json:"method"`
+	// Components restricts the identity to the listed components when non-empty, as with scoped API tokens.
+	Components []string `json:"components,omitempty"`
+}
+
+// isServiceAccount reports whether the identity is a Kubernetes service account, as used by automation such as
+// the component monitor.
+func (i *Identity) isServiceAccount() bool {
+	return i != nil && strings.HasPrefix(i.User, types.ServiceAccountUserPrefix)
+}
+
+// Authenticator identifies the caller of a request. It returns a nil identity when the request carries
+// no credentials it understands, and an error when the request carries credentials that are not valid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+type identityContextKey struct{}
+
+func withIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// identityFromContext returns the identity attached to the request context, or nil for anonymous requests.
+func identityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityContextKey{}).(*Identity)
+	return identity
+}
+
+// ProxyAuthenticator trusts identity headers set by an authenticating reverse proxy (such as an OAuth proxy),
+// but only when the request arrives directly from one of the configured proxy addresses.
+type ProxyAuthenticator struct {
+	trustedProxies []*net.IPNet
+	userHeader     string
+	groupsHeader   string
+	emailHeader    string
+}
+
+// NewProxyAuthenticator creates a ProxyAuthenticator. Trusted proxies may be given as IP addresses or CIDR ranges.
+func NewProxyAuthenticator(trustedProxies []string, userHeader, groupsHeader, emailHeader string) (*ProxyAuthenticator, error) {
+	authenticator := &ProxyAuthenticator{
+		userHeader:   userHeader,
+		groupsHeader: groupsHeader,
+		emailHeader:  emailHeader,
+	}
+
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", ip.String(), bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %s: %w", proxy, err)
+		}
+		authenticator.trustedProxies = append(authenticator.trustedProxies, network)
+	}
+
+	return authenticator, nil
+}
+
+func (p *ProxyAuthenticator) isTrustedSource(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate returns the identity asserted by the proxy headers. Identity headers arriving from
+// anywhere other than a trusted proxy are rejected, as they can only be an attempt to spoof a user.
+func (p *ProxyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	user := strings.TrimSpace(r.Header.Get(p.userHeader))
+	if user == "" {
+		return nil, nil
+	}
+	if !p.isTrustedSource(r.RemoteAddr) {
+		return nil, fmt.Errorf("identity header %s received from untrusted source %s", p.userHeader, r.RemoteAddr)
+	}
+
+	identity := &Identity{
+		User:   user,
+		Email:  strings.TrimSpace(r.Header.Get(p.emailHeader)),
+		Method: AuthMethodProxy,
+	}
+	for _, value := range r.Header.Values(p.groupsHeader) {
+		for _, group := range strings.Split(value, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				identity.Groups = append(identity.Groups, group)
+			}
+		}
+	}
+	return identity, nil
+}
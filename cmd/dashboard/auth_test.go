@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyAuthenticator(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		expectError    bool
+	}{
+		{
+			name:           "ip addresses and cidr ranges",
+			trustedProxies: []string{"127.0.0.1", " ::1 ", "10.128.0.0/14"},
+		},
+		{
+			name:           "empty entries are ignored",
+			trustedProxies: []string{""},
+		},
+		{
+			name:           "invalid ip address",
+			trustedProxies: []string{"not-an-ip"},
+			expectError:    true,
+		},
+		{
+			name:           "invalid cidr range",
+			trustedProxies: []string{"10.0.0.0/99"},
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProxyAuthenticator(tt.trustedProxies, "X-Forwarded-User", "X-Forwarded-Groups", "X-Forwarded-Email")
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProxyAuthenticator_Authenticate(t *testing.T) {
+	authenticator, err := NewProxyAuthenticator([]string{"127.0.0.1", "10.128.0.0/14"}, "X-Forwarded-User", "X-Forwarded-Groups", "X-Forwarded-Email")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		expected   *Identity
+		expectErr  bool
+	}{
+		{
+			name:       "trusted proxy with all headers",
+			remoteAddr: "127.0.0.1:43210",
+			headers: map[string][]string{
+				"X-Forwarded-User":   {"jdoe"},
+				"X-Forwarded-Email":  {"jdoe@example.com"},
+				"X-Forwarded-Groups": {"dptp, trt", "admins"},
+			},
+			expected: &Identity{User: "jdoe", Email: "jdoe@example.com", Groups: []string{"dptp", "trt", "admins"}, Method: AuthMethodProxy},
+		},
+		{
+			name:       "trusted proxy within cidr range",
+			remoteAddr: "10.129.2.7:8443",
+			headers:    map[string][]string{"X-Forwarded-User": {"jdoe"}},
+			expected:   &Identity{User: "jdoe", Method: AuthMethodProxy},
+		},
+		{
+			name:       "untrusted source is rejected",
+			remoteAddr: "192.168.1.10:5555",
+			headers:    map[string][]string{"X-Forwarded-User": {"jdoe"}},
+			expectErr:  true,
+		},
+		{
+			name:       "untrusted source without identity headers is anonymous",
+			remoteAddr: "192.168.1.10:5555",
+			expected:   nil,
+		},
+		{
+			name:       "missing user header",
+			remoteAddr: "127.0.0.1:43210",
+			headers:    map[string][]string{"X-Forwarded-Groups": {"dptp"}},
+			expected:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/components/Prow/Tide/outages", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for header, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(header, value)
+				}
+			}
+
+			identity, err := authenticator.Authenticate(req)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
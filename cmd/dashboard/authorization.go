@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Action is an operation that requires a minimum role.
+type Action string
+
+const (
+	ActionViewStatus            Action = "view status and outages"
+	ActionManageAPITokens       Action = "manage personal API tokens"
+	ActionReportSuspectedOutage Action = "report a Suspected outage"
+	ActionCreateOutage          Action = "create a Degraded or Down outage"
+	ActionUpdateOutage          Action = "update an outage"
+	ActionConfirmOutage         Action = "confirm an outage"
+	ActionEditTriageNotes       Action = "edit triage notes"
+	ActionPostOutageUpdate      Action = "post an outage update"
+	ActionWritePostmortem       Action = "write a postmortem"
+	ActionMergeOutages          Action = "merge outages"
+	ActionManageIncidents       Action = "manage incidents"
+	ActionDeleteOutage          Action = "delete an outage"
+	ActionViewAuditLog          Action = "view the audit log"
+	ActionManageDeletedOutages  Action = "view and restore deleted outages"
+	ActionDeleteIncident        Action = "delete an incident"
+)
+
+// actionRoles defines the minimum role required for each action.
+var actionRoles = map[Action]types.Role{
+	ActionViewStatus:            types.RoleViewer,
+	ActionManageAPITokens:       types.RoleViewer,
+	ActionReportSuspectedOutage: types.RoleReporter,
+	ActionCreateOutage:          types.RoleOwner,
+	ActionUpdateOutage:          types.RoleOwner,
+	ActionConfirmOutage:         types.RoleOwner,
+	ActionEditTriageNotes:       types.RoleOwner,
+	ActionPostOutageUpdate:      types.RoleOwner,
+	ActionWritePostmortem:       types.RoleOwner,
+	ActionMergeOutages:          types.RoleOwner,
+	ActionManageIncidents:       types.RoleOwner,
+	ActionDeleteOutage:          types.RoleAdmin,
+	ActionViewAuditLog:          types.RoleAdmin,
+	ActionManageDeletedOutages:  types.RoleAdmin,
+	ActionDeleteIncident:        types.RoleAdmin,
+}
+
+func inScope(identity *Identity, component *types.Component) bool {
+	if len(identity.Components) == 0 {
+		return true
+	}
+	return component != nil && slices.Contains(identity.Components, component.Name)
+}
+
+func ownerMatches(owner types.Owner, identity *Identity) bool {
+	if owner.RoverGroup != "" && slices.Contains(identity.Groups, owner.RoverGroup) {
+		return true
+	}
+	return owner.ServiceAccount != "" && identity.User == owner.ServiceAccountUser()
+}
+
+func bindingMatches(binding types.RoleBinding, identity *Identity) bool {
+	if slices.Contains(binding.Users, identity.User) {
+		return true
+	}
+	for _, group := range binding.Groups {
+		if slices.Contains(identity.Groups, group) {
+			return true
+		}
+	}
+	for _, serviceAccount := range binding.ServiceAccounts {
+		if identity.User == types.ServiceAccountUserPrefix+serviceAccount {
+			return true
+		}
+	}
+	return false
+}
+
+func higherRole(a, b types.Role) types.Role {
+	if b.Level() > a.Level() {
+		return b
+	}
+	return a
+}
+
+// roleFor returns the highest role the identity holds for the component, or globally when component is nil.
+// Anonymous callers and callers whose credentials are scoped to other components are viewers.
+func (h *Handlers) roleFor(identity *Identity, component *types.Component) types.Role {
+	if identity == nil || !inScope(identity, component) {
+		return types.RoleViewer
+	}
+
+	role := higherRole(types.RoleViewer, h.config.DefaultRole)
+	for _, binding := range h.config.RoleBindings {
+		if bindingMatches(binding, identity) {
+			role = higherRole(role, binding.Role)
+		}
+	}
+	if component == nil {
+		return role
+	}
+
+	for _, binding := range component.RoleBindings {
+		if bindingMatches(binding, identity) {
+			role = higherRole(role, binding.Role)
+		}
+	}
+	for _, owner := range component.Owners {
+		if ownerMatches(owner, identity) {
+			role = higherRole(role, types.RoleOwner)
+		}
+	}
+	return role
+}
+
+// can reports whether the identity may perform the action on the component, or globally when component is nil.
+func (h *Handlers) can(identity *Identity, component *types.Component, action Action) bool {
+	return h.roleFor(identity, component).Level() >= actionRoles[action].Level()
+}
+
+// authorize responds with 401 or 403 and returns false when the caller may not perform all of the actions.
+func (h *Handlers) authorize(w http.ResponseWriter, r *http.Request, component *types.Component, actions ...Action) bool {
+	identity := identityFromContext(r.Context())
+	for _, action := range actions {
+		if h.can(identity, component, action) {
+			continue
+		}
+		if identity == nil {
+			respondWithError(w, http.StatusUnauthorized, "Authentication required")
+			return false
+		}
+
+		logger := h.logger.WithFields(logrus.Fields{
+			"user":   identity.User,
+			"action": action,
+		})
+		if component != nil {
+			logger = logger.WithField("component", component.Name)
+		}
+		logger.Warn("Denied request")
+
+		respondWithError(w, http.StatusForbidden, h.denialMessage(identity, component, action))
+		return false
+	}
+	return true
+}
+
+// authorizeAnyComponent is like authorize, but for actions that span components: holding the required role
+// globally or on any single component is sufficient.
+func (h *Handlers) authorizeAnyComponent(w http.ResponseWriter, r *http.Request, action Action) bool {
+	identity := identityFromContext(r.Context())
+	for i := range h.config.Components {
+		if h.can(identity, &h.config.Components[i], action) {
+			return true
+		}
+	}
+	return h.authorize(w, r, nil, action)
+}
+
+// authorizeAutomation responds with 403 and returns false when a service account tries to create or resolve the
+// outages of a sub-component that is not managed, since those are left to humans.
+func (h *Handlers) authorizeAutomation(w http.ResponseWriter, r *http.Request, component *types.Component, subComponent *types.SubComponent, action string) bool {
+	identity := identityFromContext(r.Context())
+	if subComponent.Managed || !identity.isServiceAccount() {
+		return true
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user":          identity.User,
+		"component":     component.Name,
+		"sub_component": subComponent.Name,
+		"action":        action,
+	}).Warn("Denied automated request for unmanaged sub-component")
+
+	respondWithError(w, http.StatusForbidden, fmt.Sprintf("Sub-component %q of component %q is not managed, so service accounts may not %s its outages",
+		subComponent.Name, component.Name, action))
+	return false
+}
+
+func (h *Handlers) denialMessage(identity *Identity, component *types.Component, action Action) string {
+	required := actionRoles[action]
+	if component == nil {
+		return fmt.Sprintf("User %q is not allowed to %s: requires the %s role", identity.User, action, required)
+	}
+	if !inScope(identity, component) {
+		return fmt.Sprintf("Credentials are not scoped to component %q", component.Name)
+	}
+
+	message := fmt.Sprintf("User %q has the %s role on component %q, but to %s requires the %s role",
+		identity.User, h.roleFor(identity, component), component.Name, action, required)
+	if required == types.RoleOwner {
+		allowed := make([]string, 0, len(component.Owners))
+		for _, owner := range component.Owners {
+			allowed = append(allowed, owner.String())
+		}
+		allowedOwners := "none configured"
+		if len(allowed) > 0 {
+			allowedOwners = strings.Join(allowed, ", ")
+		}
+		message += fmt.Sprintf(". Allowed owners: %s", allowedOwners)
+	}
+	return message
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionMatrix(t *testing.T) {
+	actions := []Action{
+		ActionViewStatus,
+		ActionManageAPITokens,
+		ActionReportSuspectedOutage,
+		ActionCreateOutage,
+		ActionUpdateOutage,
+		ActionConfirmOutage,
+		ActionEditTriageNotes,
+		ActionPostOutageUpdate,
+		ActionWritePostmortem,
+		ActionMergeOutages,
+		ActionManageIncidents,
+		ActionDeleteOutage,
+		ActionViewAuditLog,
+		ActionManageDeletedOutages,
+		ActionDeleteIncident,
+	}
+
+	expected := map[types.Role][]Action{
+		types.RoleViewer:   {ActionViewStatus, ActionManageAPITokens},
+		types.RoleReporter: {ActionViewStatus, ActionManageAPITokens, ActionReportSuspectedOutage},
+		types.RoleOwner: {ActionViewStatus, ActionManageAPITokens, ActionReportSuspectedOutage, ActionCreateOutage,
+			ActionUpdateOutage, ActionConfirmOutage, ActionEditTriageNotes, ActionPostOutageUpdate, ActionWritePostmortem,
+			ActionMergeOutages, ActionManageIncidents},
+		types.RoleAdmin: actions,
+	}
+
+	component := &types.Component{Name: "Prow"}
+	for role, allowed := range expected {
+		handlers := &Handlers{config: &types.Config{
+			DefaultRole:  types.RoleViewer,
+			RoleBindings: []types.RoleBinding{{Role: role, Users: []string{"jdoe"}}},
+		}}
+		identity := &Identity{User: "jdoe"}
+
+		for _, action := range actions {
+			t.Run(string(role)+"/"+string(action), func(t *testing.T) {
+				assert.Equal(t, slices.Contains(allowed, action), handlers.can(identity, component, action))
+			})
+		}
+	}
+}
+
+func TestRoleFor(t *testing.T) {
+	prow := &types.Component{
+		Name: "Prow",
+		Owners: []types.Owner{
+			{RoverGroup: "dptp"},
+			{ServiceAccount: "ship-status:component-monitor"},
+		},
+		RoleBindings: []types.RoleBinding{
+			{Role: types.RoleReporter, Groups: []string{"openshift-eng"}},
+		},
+	}
+	config := &types.Config{
+		Components:  []types.Component{*prow},
+		DefaultRole: types.RoleViewer,
+		RoleBindings: []types.RoleBinding{
+			{Role: types.RoleAdmin, Groups: []string{"ship-admins"}},
+			{Role: types.RoleOwner, ServiceAccounts: []string{"ci:automation"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		defaultRole types.Role
+		identity    *Identity
+		component   *types.Component
+		expected    types.Role
+	}{
+		{
+			name:      "anonymous is a viewer",
+			identity:  nil,
+			component: prow,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:      "authenticated without bindings gets the default role",
+			identity:  &Identity{User: "jdoe"},
+			component: prow,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:        "configured default role",
+			defaultRole: types.RoleReporter,
+			identity:    &Identity{User: "jdoe"},
+			component:   prow,
+			expected:    types.RoleReporter,
+		},
+		{
+			name:      "component role binding by group",
+			identity:  &Identity{User: "jdoe", Groups: []string{"openshift-eng"}},
+			component: prow,
+			expected:  types.RoleReporter,
+		},
+		{
+			name:      "member of owner group",
+			identity:  &Identity{User: "jdoe", Groups: []string{"openshift-eng", "dptp"}},
+			component: prow,
+			expected:  types.RoleOwner,
+		},
+		{
+			name:      "owner service account",
+			identity:  &Identity{User: "system:serviceaccount:ship-status:component-monitor"},
+			component: prow,
+			expected:  types.RoleOwner,
+		},
+		{
+			name:      "service account in another namespace",
+			identity:  &Identity{User: "system:serviceaccount:other:component-monitor"},
+			component: prow,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:      "global service account binding",
+			identity:  &Identity{User: "system:serviceaccount:ci:automation"},
+			component: prow,
+			expected:  types.RoleOwner,
+		},
+		{
+			name:      "global admin",
+			identity:  &Identity{User: "admin", Groups: []string{"ship-admins"}},
+			component: prow,
+			expected:  types.RoleAdmin,
+		},
+		{
+			name:      "global role without component ignores component bindings",
+			identity:  &Identity{User: "jdoe", Groups: []string{"dptp"}},
+			component: nil,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:      "user named like an owner group",
+			identity:  &Identity{User: "dptp"},
+			component: prow,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:      "owner using token scoped to the component",
+			identity:  &Identity{User: "jdoe", Groups: []string{"dptp"}, Method: AuthMethodAPIToken, Components: []string{"Prow"}},
+			component: prow,
+			expected:  types.RoleOwner,
+		},
+		{
+			name:      "admin using token scoped to another component",
+			identity:  &Identity{User: "admin", Groups: []string{"ship-admins"}, Method: AuthMethodAPIToken, Components: []string{"Sippy"}},
+			component: prow,
+			expected:  types.RoleViewer,
+		},
+		{
+			name:      "scoped token has no global role",
+			identity:  &Identity{User: "admin", Groups: []string{"ship-admins"}, Method: AuthMethodAPIToken, Components: []string{"Prow"}},
+			component: nil,
+			expected:  types.RoleViewer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testConfig := *config
+			if tt.defaultRole != "" {
+				testConfig.DefaultRole = tt.defaultRole
+			}
+			handlers := &Handlers{config: &testConfig}
+			assert.Equal(t, tt.expected, handlers.roleFor(tt.identity, tt.component))
+		})
+	}
+}
+
+func TestUpdateOutageRequest_RequiredActions(t *testing.T) {
+	severity := string(types.SeverityDown)
+	notes := "notes"
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		request  UpdateOutageRequest
+		expected []Action
+	}{
+		{
+			name:     "empty request",
+			request:  UpdateOutageRequest{},
+			expected: []Action{ActionUpdateOutage},
+		},
+		{
+			name:     "severity change",
+			request:  UpdateOutageRequest{Severity: &severity},
+			expected: []Action{ActionUpdateOutage},
+		},
+		{
+			name:     "confirmation only",
+			request:  UpdateOutageRequest{ConfirmedAt: &now},
+			expected: []Action{ActionConfirmOutage},
+		},
+		{
+			name:     "resolution with triage notes",
+			request:  UpdateOutageRequest{EndTime: &now, TriageNotes: &notes},
+			expected: []Action{ActionUpdateOutage, ActionEditTriageNotes},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.request.requiredActions())
+		})
+	}
+}
+
+func TestAuthorizeAutomation(t *testing.T) {
+	component := &types.Component{Name: "Prow"}
+	managed := &types.SubComponent{Name: "Deck", Managed: true}
+	unmanaged := &types.SubComponent{Name: "Tide"}
+	serviceAccount := &Identity{User: "system:serviceaccount:ship-status:component-monitor", Method: AuthMethodTokenReview}
+	human := &Identity{User: "jdoe", Method: AuthMethodProxy}
+
+	tests := []struct {
+		name         string
+		identity     *Identity
+		subComponent *types.SubComponent
+		allowed      bool
+	}{
+		{name: "service account on managed sub-component", identity: serviceAccount, subComponent: managed, allowed: true},
+		{name: "service account on unmanaged sub-component", identity: serviceAccount, subComponent: unmanaged, allowed: false},
+		{name: "human on unmanaged sub-component", identity: human, subComponent: unmanaged, allowed: true},
+		{name: "anonymous on unmanaged sub-component", identity: nil, subComponent: unmanaged, allowed: true},
+	}
+
+	handlers := &Handlers{config: &types.Config{}, logger: logrus.New()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/components/Prow/Tide/outages", nil)
+			req = req.WithContext(withIdentity(req.Context(), tt.identity))
+			recorder := httptest.NewRecorder()
+
+			assert.Equal(t, tt.allowed, handlers.authorizeAutomation(recorder, req, component, tt.subComponent, "create"))
+			if !tt.allowed {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Contains(t, recorder.Body.String(), "not managed")
+			}
+		})
+	}
+}
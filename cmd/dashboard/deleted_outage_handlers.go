@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"ship-status-dash/pkg/types"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (h *Handlers) findDeletedOutage(outageId string) (types.Outage, error) {
+	var outage types.Outage
+	err := h.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", outageId).First(&outage).Error
+	return outage, err
+}
+
+// GetDeletedOutagesJSON lists soft-deleted outages, most recently deleted first. Results can be narrowed with the
+// component and sub_component query parameters.
+func (h *Handlers) GetDeletedOutagesJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageDeletedOutages) {
+		return
+	}
+
+	query := h.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if componentName := r.URL.Query().Get("component"); componentName != "" {
+		component := h.getComponent(componentName)
+		if component == nil {
+			respondWithError(w, http.StatusNotFound, "Component not found")
+			return
+		}
+		subComponents := []string{}
+		for _, subComponent := range component.Subcomponents {
+			subComponents = append(subComponents, subComponent.Name)
+		}
+		query = query.Where("component_name IN ?", subComponents)
+	}
+	if subComponentName := r.URL.Query().Get("sub_component"); subComponentName != "" {
+		query = query.Where("component_name = ?", subComponentName)
+	}
+
+	var outages []types.Outage
+	if err := query.Order("deleted_at DESC").Find(&outages).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to query deleted outages from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deleted outages")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, outages)
+}
+
+// GetDeletedOutageJSON retrieves a single soft-deleted outage, including who deleted it and why.
+func (h *Handlers) GetDeletedOutageJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageDeletedOutages) {
+		return
+	}
+
+	outageId := mux.Vars(r)["outageId"]
+	outage, err := h.findDeletedOutage(outageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Deleted outage not found")
+			return
+		}
+		h.logger.WithFields(logrus.Fields{
+			"outage_id": outageId,
+			"error":     err,
+		}).Error("Failed to query deleted outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deleted outage")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, outage)
+}
+
+// RestoreOutageJSON undoes the soft-deletion of an outage. Outages that were merged into another one cannot be
+// restored, since their reports and updates were moved to the outage they were merged into.
+func (h *Handlers) RestoreOutageJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionManageDeletedOutages) {
+		return
+	}
+
+	outageId := mux.Vars(r)["outageId"]
+	logger := h.logger.WithFields(logrus.Fields{
+		"outage_id": outageId,
+		"user":      identityFromContext(r.Context()).User,
+	})
+
+	outage, err := h.findDeletedOutage(outageId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Deleted outage not found")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query deleted outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deleted outage")
+		return
+	}
+
+	if outage.MergedInto != nil {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Outage was merged into outage %d and cannot be restored", *outage.MergedInto))
+		return
+	}
+
+	if err := h.db.Unscoped().Model(&outage).Updates(map[string]interface{}{
+		"deleted_at":      nil,
+		"deleted_by":      nil,
+		"deletion_reason": nil,
+		"version":         gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to restore outage in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to restore outage")
+		return
+	}
+
+	if err := h.db.First(&outage, outage.ID).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to query restored outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get restored outage")
+		return
+	}
+
+	logger.Info("Successfully restored outage")
+	respondWithJSON(w, http.StatusOK, outage)
+}
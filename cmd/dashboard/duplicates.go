@@ -0,0 +1,427 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// duplicatePolicy determines what happens when an outage is reported for a sub-component that already has an
+// active outage.
+type duplicatePolicy string
+
+const (
+	// duplicateReject rejects the new outage with a 409 pointing to the active one.
+	duplicateReject duplicatePolicy = "reject"
+	// duplicateAttach records the new outage as a report of the active one.
+	duplicateAttach duplicatePolicy = "attach"
+	// duplicateCreate opens a separate outage regardless, for unrelated problems with the same sub-component.
+	duplicateCreate duplicatePolicy = "create"
+)
+
+// errOutageNotFound aborts a merge when some of the outages do not exist for the sub-component.
+var errOutageNotFound = errors.New("outage not found")
+
+// errMergeConflict aborts a merge of outages whose incidents or postmortems cannot be combined.
+var errMergeConflict = errors.New("outages cannot be merged")
+
+// outageLockClass namespaces the per sub-component advisory locks taken while checking for duplicates.
+const outageLockClass = 1
+
+func (p duplicatePolicy) isValid() bool {
+	switch p {
+	case duplicateReject, duplicateAttach, duplicateCreate:
+		return true
+	}
+	return false
+}
+
+func outageIsActive(outage *types.Outage, now time.Time) bool {
+	return !outage.EndTime.Valid || outage.EndTime.Time.After(now)
+}
+
+// lockActiveOutage serializes outage creation for the sub-component until the transaction ends, and returns its
+// earliest active outage, if any.
+func lockActiveOutage(tx *gorm.DB, subComponentName string) (*types.Outage, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", outageLockClass, subComponentName).Error; err != nil {
+		return nil, err
+	}
+
+	var outages []types.Outage
+	if err := tx.Where("component_name = ? AND (end_time IS NULL OR end_time > ?)", subComponentName, time.Now()).
+		Order("start_time").Limit(1).Find(&outages).Error; err != nil {
+		return nil, err
+	}
+	if len(outages) == 0 {
+		return nil, nil
+	}
+	return &outages[0], nil
+}
+
+func outagePath(component *types.Component, outage *types.Outage) string {
+	return fmt.Sprintf("/api/components/%s/%s/outages/%d", component.Name, outage.ComponentName, outage.ID)
+}
+
+// DuplicateOutageResponse is returned with a 409 when an outage is reported for a sub-component that already has one.
+type DuplicateOutageResponse struct {
+	Error          string       `json:"error"`
+	ExistingOutage types.Outage `json:"existing_outage"`
+}
+
+// handleDuplicateOutage answers a request to create an outage while another one is active, according to the policy.
+func (h *Handlers) handleDuplicateOutage(w http.ResponseWriter, logger *logrus.Entry, component *types.Component, existing, reported *types.Outage, policy duplicatePolicy) {
+	logger = logger.WithField("existing_outage_id", existing.ID)
+	w.Header().Set("Location", outagePath(component, existing))
+
+	if policy != duplicateAttach {
+		logger.Info("Rejected duplicate outage")
+		respondWithJSON(w, http.StatusConflict, DuplicateOutageResponse{
+			Error:          fmt.Sprintf("Sub-component %s already has an active outage: %d", existing.ComponentName, existing.ID),
+			ExistingOutage: *existing,
+		})
+		return
+	}
+
+	report := types.OutageReport{
+		OutageID:       existing.ID,
+		Severity:       reported.Severity,
+		Description:    reported.Description,
+		DiscoveredFrom: reported.DiscoveredFrom,
+		ReportedBy:     reported.CreatedBy,
+	}
+	if err := h.db.Create(&report).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to attach report to outage in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to attach report to outage")
+		return
+	}
+	if err := h.db.Preload("Reports").Preload("Updates", orderOutageUpdates).First(existing, existing.ID).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return
+	}
+
+	logger.Infof("Attached report to existing outage: %d", report.ID)
+	w.Header().Set("ETag", outageETag(existing))
+	respondWithJSON(w, http.StatusOK, existing)
+}
+
+// MergeOutagesRequest represents the body of a request to merge outages of a sub-component.
+type MergeOutagesRequest struct {
+	OutageIDs []uint `json:"outage_ids"`
+	// Into is the ID of the outage to keep, and defaults to the one that started first.
+	Into *uint `json:"into,omitempty"`
+}
+
+func (m *MergeOutagesRequest) validate() (string, bool) {
+	if len(m.OutageIDs) < 2 {
+		return "At least two outage_ids are required", false
+	}
+	seen := map[uint]bool{}
+	for _, id := range m.OutageIDs {
+		if seen[id] {
+			return fmt.Sprintf("Duplicate outage ID: %d", id), false
+		}
+		seen[id] = true
+	}
+	if m.Into != nil && !seen[*m.Into] {
+		return "into must be one of outage_ids", false
+	}
+	return "", true
+}
+
+// mergeTarget returns the index of the outage the others are merged into.
+func mergeTarget(outages []types.Outage, into *uint) int {
+	target := 0
+	for i, outage := range outages {
+		if into != nil {
+			if outage.ID == *into {
+				return i
+			}
+			continue
+		}
+		if outage.StartTime.Before(outages[target].StartTime) {
+			target = i
+		}
+	}
+	return target
+}
+
+// mergedColumns returns the columns of target after folding the other outages into it: the earliest start, the
+// latest end (or none if any of them is still ongoing), the worst severity, the earliest confirmation, the
+// combined descriptions and triage notes, the union of the labels and links, and the incident of any of them.
+// Labels of the target win over conflicting values.
+func mergedColumns(target types.Outage, others []types.Outage) map[string]interface{} {
+	startTime := target.StartTime
+	endTime := target.EndTime
+	resolvedBy := target.ResolvedBy
+	severity := target.Severity
+	confirmedAt := target.ConfirmedAt
+	confirmedBy := target.ConfirmedBy
+	descriptions := []string{target.Description}
+	var notes []string
+	if target.TriageNotes != nil && *target.TriageNotes != "" {
+		notes = append(notes, *target.TriageNotes)
+	}
+	labels := maps.Clone(target.Labels)
+	links := slices.Clone(target.Links)
+	incidentID := target.IncidentID
+
+	for _, other := range others {
+		if other.StartTime.Before(startTime) {
+			startTime = other.StartTime
+		}
+		if !other.EndTime.Valid {
+			endTime = sql.NullTime{}
+			resolvedBy = nil
+		} else if endTime.Valid && other.EndTime.Time.After(endTime.Time) {
+			endTime = other.EndTime
+			resolvedBy = other.ResolvedBy
+		}
+		if types.GetSeverityLevel(other.Severity) > types.GetSeverityLevel(severity) {
+			severity = other.Severity
+		}
+		if other.ConfirmedAt.Valid && (!confirmedAt.Valid || other.ConfirmedAt.Time.Before(confirmedAt.Time)) {
+			confirmedAt = other.ConfirmedAt
+			confirmedBy = other.ConfirmedBy
+		}
+		if other.Description != "" {
+			descriptions = append(descriptions, fmt.Sprintf("Merged from outage %d:\n%s", other.ID, other.Description))
+		}
+		if other.TriageNotes != nil && *other.TriageNotes != "" {
+			notes = append(notes, fmt.Sprintf("Merged from outage %d:\n%s", other.ID, *other.TriageNotes))
+		}
+		for key, value := range other.Labels {
+			if _, exists := labels[key]; !exists {
+				if labels == nil {
+					labels = map[string]string{}
+				}
+				labels[key] = value
+			}
+		}
+		for _, link := range other.Links {
+			if !slices.ContainsFunc(links, func(existing types.ExternalLink) bool { return existing.URL == link.URL }) {
+				links = append(links, link)
+			}
+		}
+		if incidentID == nil {
+			incidentID = other.IncidentID
+		}
+	}
+
+	columns := map[string]interface{}{
+		"start_time":   startTime,
+		"end_time":     endTime,
+		"resolved_by":  resolvedBy,
+		"severity":     severity,
+		"confirmed_at": confirmedAt,
+		"confirmed_by": confirmedBy,
+	}
+	if len(descriptions) > 1 {
+		if descriptions[0] == "" {
+			descriptions = descriptions[1:]
+		}
+		columns["description"] = strings.Join(descriptions, "\n\n")
+	}
+	if len(notes) > 0 {
+		columns["triage_notes"] = strings.Join(notes, "\n\n")
+	}
+	if len(labels) > 0 {
+		columns["labels"] = jsonColumn(labels)
+	}
+	if len(links) > 0 {
+		columns["links"] = jsonColumn(links)
+	}
+	if target.IncidentID == nil && incidentID != nil {
+		columns["incident_id"] = *incidentID
+	}
+	return columns
+}
+
+// linkedIncidents returns the sorted IDs of the incidents that the outages are linked to.
+func linkedIncidents(outages []types.Outage) []uint {
+	var incidentIDs []uint
+	for _, outage := range outages {
+		if outage.IncidentID != nil && !slices.Contains(incidentIDs, *outage.IncidentID) {
+			incidentIDs = append(incidentIDs, *outage.IncidentID)
+		}
+	}
+	slices.Sort(incidentIDs)
+	return incidentIDs
+}
+
+// updateOutageVersion writes the columns of an outage unless it changed since it was read, in which case it
+// returns errOutageModified.
+func updateOutageVersion(tx *gorm.DB, outage types.Outage, columns map[string]interface{}) error {
+	result := tx.Model(&types.Outage{}).Where("id = ? AND version = ?", outage.ID, outage.Version).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errOutageModified
+	}
+	return nil
+}
+
+// MergeOutagesJSON folds several outages of a sub-component into one. The other outages are soft-deleted and
+// their reports, updates, postmortem and incident are moved to the remaining outage, so outages linked to different
+// incidents or with more than one postmortem between them cannot be merged. Since nothing the merged outages hold
+// is lost, merging only needs the owner role, unlike deleting an outage. The merge fails with 412 when any of the
+// outages is changed by someone else while it runs.
+func (h *Handlers) MergeOutagesJSON(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	componentName := vars["componentName"]
+	subComponentName := vars["subComponentName"]
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"component":     componentName,
+		"sub_component": subComponentName,
+	})
+
+	component := h.getComponent(componentName)
+	if component == nil {
+		respondWithError(w, http.StatusNotFound, "Component not found")
+		return
+	}
+	if component.GetSubComponent(subComponentName) == nil {
+		respondWithError(w, http.StatusNotFound, "Sub-component not found")
+		return
+	}
+
+	if !h.authorize(w, r, component, ActionMergeOutages) {
+		return
+	}
+
+	var req MergeOutagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	logger = logger.WithFields(logrus.Fields{
+		"outage_ids": req.OutageIDs,
+		"user":       identity.User,
+	})
+
+	var missing []uint
+	var conflict string
+	var target types.Outage
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var outages []types.Outage
+		if err := tx.Where("id IN ? AND component_name = ?", req.OutageIDs, subComponentName).Find(&outages).Error; err != nil {
+			return err
+		}
+		for _, id := range req.OutageIDs {
+			if !slices.ContainsFunc(outages, func(outage types.Outage) bool { return outage.ID == id }) {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			return errOutageNotFound
+		}
+		if incidentIDs := linkedIncidents(outages); len(incidentIDs) > 1 {
+			conflict = fmt.Sprintf("Outages linked to different incidents cannot be merged: incidents %v", incidentIDs)
+			return errMergeConflict
+		}
+		var postmortems []types.Postmortem
+		if err := tx.Where("outage_id IN ?", req.OutageIDs).Order("outage_id").Find(&postmortems).Error; err != nil {
+			return err
+		}
+		if len(postmortems) > 1 {
+			withPostmortems := make([]uint, 0, len(postmortems))
+			for _, postmortem := range postmortems {
+				withPostmortems = append(withPostmortems, *postmortem.OutageID)
+			}
+			conflict = fmt.Sprintf("Only one of the merged outages may have a postmortem: outages %v have one", withPostmortems)
+			return errMergeConflict
+		}
+
+		targetIndex := mergeTarget(outages, req.Into)
+		target = outages[targetIndex]
+		others := slices.Delete(slices.Clone(outages), targetIndex, targetIndex+1)
+		otherIDs := make([]uint, 0, len(others))
+		for _, other := range others {
+			otherIDs = append(otherIDs, other.ID)
+		}
+
+		// Every outage is only written at the version it was read at, so that concurrent edits are never lost
+		columns := mergedColumns(target, others)
+		columns["version"] = gorm.Expr("version + 1")
+		if err := updateOutageVersion(tx, target, columns); err != nil {
+			return err
+		}
+		if err := tx.Model(&types.OutageReport{}).Where("outage_id IN ?", otherIDs).Update("outage_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&types.OutageUpdate{}).Where("outage_id IN ?", otherIDs).Update("outage_id", target.ID).Error; err != nil {
+			return err
+		}
+		if len(postmortems) == 1 && *postmortems[0].OutageID != target.ID {
+			if err := tx.Model(&postmortems[0]).Update("outage_id", target.ID).Error; err != nil {
+				return err
+			}
+		}
+		if incidentID, linked := columns["incident_id"]; linked {
+			if err := tx.Create(&types.IncidentEvent{
+				IncidentID: incidentID.(uint),
+				Kind:       types.IncidentEventOutageLinked,
+				Message:    fmt.Sprintf("Linked outage %d of %s, which outages %v were merged into", target.ID, target.ComponentName, otherIDs),
+				Author:     identity.User,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		for _, other := range others {
+			if err := updateOutageVersion(tx, other, map[string]interface{}{
+				"deleted_by":      identity.User,
+				"deletion_reason": fmt.Sprintf("Merged into outage %d", target.ID),
+				"merged_into":     target.ID,
+				"incident_id":     nil,
+				"version":         gorm.Expr("version + 1"),
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id IN ?", otherIDs).Delete(&types.Outage{}).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Reports").Preload("Updates", orderOutageUpdates).First(&target, target.ID).Error
+	})
+	if errors.Is(err, errOutageNotFound) {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Outages not found for sub-component %s: %v", subComponentName, missing))
+		return
+	}
+	if errors.Is(err, errMergeConflict) {
+		respondWithError(w, http.StatusConflict, conflict)
+		return
+	}
+	if errors.Is(err, errOutageModified) {
+		logger.Warn("Outage was modified concurrently")
+		respondWithPreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to merge outages in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to merge outages")
+		return
+	}
+
+	logger.Infof("Successfully merged outages into outage: %d", target.ID)
+	w.Header().Set("ETag", outageETag(&target))
+	respondWithJSON(w, http.StatusOK, target)
+}
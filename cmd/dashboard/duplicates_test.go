@@ -0,0 +1,208 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestOutageIsActive(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, outageIsActive(&types.Outage{}, now))
+	assert.True(t, outageIsActive(&types.Outage{EndTime: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}, now))
+	assert.False(t, outageIsActive(&types.Outage{EndTime: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}, now))
+}
+
+func TestMergeOutagesRequest_Validate(t *testing.T) {
+	uintPtr := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name            string
+		request         MergeOutagesRequest
+		expectedMessage string
+	}{
+		{
+			name:    "two outages",
+			request: MergeOutagesRequest{OutageIDs: []uint{1, 2}},
+		},
+		{
+			name:    "explicit target",
+			request: MergeOutagesRequest{OutageIDs: []uint{1, 2, 3}, Into: uintPtr(3)},
+		},
+		{
+			name:            "single outage",
+			request:         MergeOutagesRequest{OutageIDs: []uint{1}},
+			expectedMessage: "At least two outage_ids are required",
+		},
+		{
+			name:            "repeated outage",
+			request:         MergeOutagesRequest{OutageIDs: []uint{1, 1}},
+			expectedMessage: "Duplicate outage ID: 1",
+		},
+		{
+			name:            "target not being merged",
+			request:         MergeOutagesRequest{OutageIDs: []uint{1, 2}, Into: uintPtr(3)},
+			expectedMessage: "into must be one of outage_ids",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := tt.request.validate()
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
+
+func TestMergeTarget(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	outages := []types.Outage{
+		{Model: gorm.Model{ID: 1}, StartTime: start.Add(time.Hour)},
+		{Model: gorm.Model{ID: 2}, StartTime: start},
+		{Model: gorm.Model{ID: 3}, StartTime: start.Add(2 * time.Hour)},
+	}
+	into := uint(3)
+
+	assert.Equal(t, 1, mergeTarget(outages, nil))
+	assert.Equal(t, 2, mergeTarget(outages, &into))
+}
+
+func TestMergedColumns(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) sql.NullTime { return sql.NullTime{Time: start.Add(offset), Valid: true} }
+	strPtr := func(s string) *string { return &s }
+	uintPtr := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name     string
+		target   types.Outage
+		others   []types.Outage
+		expected map[string]interface{}
+	}{
+		{
+			name: "resolved outages keep the earliest start, latest end and worst severity",
+			target: types.Outage{
+				Severity: types.SeverityDegraded, StartTime: start.Add(time.Hour), EndTime: at(2 * time.Hour),
+				ResolvedBy: strPtr("alice"), Description: "Tide is slow", TriageNotes: strPtr("first notes"),
+			},
+			others: []types.Outage{
+				{
+					Model: gorm.Model{ID: 7}, Severity: types.SeverityDown, StartTime: start, EndTime: at(3 * time.Hour),
+					ResolvedBy: strPtr("bob"), ConfirmedAt: at(30 * time.Minute), ConfirmedBy: strPtr("carol"),
+					Description: "Tide is not merging", TriageNotes: strPtr("second notes"),
+				},
+				{Model: gorm.Model{ID: 8}, Severity: types.SeveritySuspected, StartTime: start.Add(90 * time.Minute), EndTime: at(time.Hour)},
+			},
+			expected: map[string]interface{}{
+				"start_time":   start,
+				"end_time":     at(3 * time.Hour),
+				"resolved_by":  strPtr("bob"),
+				"severity":     types.SeverityDown,
+				"confirmed_at": at(30 * time.Minute),
+				"confirmed_by": strPtr("carol"),
+				"description":  "Tide is slow\n\nMerged from outage 7:\nTide is not merging",
+				"triage_notes": "first notes\n\nMerged from outage 7:\nsecond notes",
+			},
+		},
+		{
+			name: "labels and links are combined",
+			target: types.Outage{
+				Severity: types.SeverityDown, StartTime: start,
+				Labels: map[string]string{"cause": "github"},
+				Links:  []types.ExternalLink{{Type: types.ExternalLinkJira, URL: "https://issues.example.com/DPTP-1"}},
+			},
+			others: []types.Outage{
+				{
+					Model: gorm.Model{ID: 2}, Severity: types.SeverityDown, StartTime: start,
+					Labels: map[string]string{"cause": "quay", "team": "dptp"},
+					Links: []types.ExternalLink{
+						{Type: types.ExternalLinkJira, URL: "https://issues.example.com/DPTP-1"},
+						{Type: types.ExternalLinkSlack, URL: "https://slack.example.com/archives/C1/p1"},
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"start_time":   start,
+				"end_time":     sql.NullTime{},
+				"resolved_by":  (*string)(nil),
+				"severity":     types.SeverityDown,
+				"confirmed_at": sql.NullTime{},
+				"confirmed_by": (*string)(nil),
+				"labels":       `{"cause":"github","team":"dptp"}`,
+				"links":        `[{"type":"jira","url":"https://issues.example.com/DPTP-1"},{"type":"slack","url":"https://slack.example.com/archives/C1/p1"}]`,
+			},
+		},
+		{
+			name:   "an ongoing outage keeps the merged outage ongoing",
+			target: types.Outage{Severity: types.SeverityDown, StartTime: start, EndTime: at(time.Hour), ResolvedBy: strPtr("alice")},
+			others: []types.Outage{
+				{Model: gorm.Model{ID: 2}, Severity: types.SeverityDegraded, StartTime: start.Add(time.Minute), Description: "Deck is down"},
+				{Model: gorm.Model{ID: 3}, Severity: types.SeverityDegraded, StartTime: start.Add(time.Minute), EndTime: at(2 * time.Hour)},
+			},
+			expected: map[string]interface{}{
+				"start_time":   start,
+				"end_time":     sql.NullTime{},
+				"resolved_by":  (*string)(nil),
+				"severity":     types.SeverityDown,
+				"confirmed_at": sql.NullTime{},
+				"confirmed_by": (*string)(nil),
+				"description":  "Merged from outage 2:\nDeck is down",
+			},
+		},
+		{
+			name:   "the incident of a merged outage moves to the target",
+			target: types.Outage{Severity: types.SeverityDown, StartTime: start},
+			others: []types.Outage{
+				{Model: gorm.Model{ID: 2}, Severity: types.SeverityDown, StartTime: start},
+				{Model: gorm.Model{ID: 3}, Severity: types.SeverityDown, StartTime: start, IncidentID: uintPtr(5)},
+			},
+			expected: map[string]interface{}{
+				"start_time":   start,
+				"end_time":     sql.NullTime{},
+				"resolved_by":  (*string)(nil),
+				"severity":     types.SeverityDown,
+				"confirmed_at": sql.NullTime{},
+				"confirmed_by": (*string)(nil),
+				"incident_id":  uint(5),
+			},
+		},
+		{
+			name:   "the target keeps its incident",
+			target: types.Outage{Severity: types.SeverityDown, StartTime: start, IncidentID: uintPtr(5)},
+			others: []types.Outage{{Model: gorm.Model{ID: 2}, Severity: types.SeverityDown, StartTime: start, IncidentID: uintPtr(5)}},
+			expected: map[string]interface{}{
+				"start_time":   start,
+				"end_time":     sql.NullTime{},
+				"resolved_by":  (*string)(nil),
+				"severity":     types.SeverityDown,
+				"confirmed_at": sql.NullTime{},
+				"confirmed_by": (*string)(nil),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mergedColumns(tt.target, tt.others))
+		})
+	}
+}
+
+func TestLinkedIncidents(t *testing.T) {
+	uintPtr := func(u uint) *uint { return &u }
+	outages := []types.Outage{
+		{IncidentID: uintPtr(9)},
+		{},
+		{IncidentID: uintPtr(4)},
+		{IncidentID: uintPtr(9)},
+	}
+	assert.Equal(t, []uint{4, 9}, linkedIncidents(outages))
+	assert.Empty(t, linkedIncidents([]types.Outage{{}}))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"strconv"
+	"strings"
+)
+
+// errOutageModified is returned when a conditional write finds that the outage changed since it was read.
+var errOutageModified = errors.New("outage has been modified")
+
+// outageETag returns the strong entity tag for the current version of the outage.
+func outageETag(outage *types.Outage) string {
+	return `"` + strconv.FormatUint(uint64(outage.Version), 10) + `"`
+}
+
+// ifMatchSatisfied reports whether the If-Match header of the request, if any, matches the entity tag.
+// Weak entity tags never match, as If-Match requires a strong comparison.
+func ifMatchSatisfied(r *http.Request, etag string) bool {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func respondWithPreconditionFailed(w http.ResponseWriter) {
+	respondWithError(w, http.StatusPreconditionFailed, "Outage has been modified since it was retrieved; fetch the latest version and retry")
+}
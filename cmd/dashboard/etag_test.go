@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchSatisfied(t *testing.T) {
+	etag := outageETag(&types.Outage{Version: 3})
+	assert.Equal(t, `"3"`, etag)
+
+	tests := []struct {
+		name     string
+		ifMatch  []string
+		expected bool
+	}{
+		{
+			name:     "no precondition",
+			expected: true,
+		},
+		{
+			name:     "matching version",
+			ifMatch:  []string{`"3"`},
+			expected: true,
+		},
+		{
+			name:     "stale version",
+			ifMatch:  []string{`"2"`},
+			expected: false,
+		},
+		{
+			name:     "any version",
+			ifMatch:  []string{"*"},
+			expected: true,
+		},
+		{
+			name:     "one of several versions",
+			ifMatch:  []string{`"1", "3"`},
+			expected: true,
+		},
+		{
+			name:     "weak tags never match",
+			ifMatch:  []string{`W/"3"`},
+			expected: false,
+		},
+		{
+			name:     "unquoted version",
+			ifMatch:  []string{"3"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/api/components/Prow/Tide/outages/1", nil)
+			for _, value := range tt.ifMatch {
+				req.Header.Add("If-Match", value)
+			}
+			assert.Equal(t, tt.expected, ifMatchSatisfied(req, etag))
+		})
+	}
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"ship-status-dash/pkg/types"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -67,6 +69,12 @@ func (h *Handlers) validateOutage(outage *types.Outage) (string, bool) {
 	if outage.CreatedBy == "" {
 		return "CreatedBy is required", false
 	}
+	if message, valid := validateLabels(outage.Labels); !valid {
+		return message, false
+	}
+	if message, valid := validateLinks(outage.Links); !valid {
+		return message, false
+	}
 	return "", true
 }
 
@@ -81,6 +89,9 @@ func (h *Handlers) HealthJSON(w http.ResponseWriter, r *http.Request) {
 
 // GetComponentsJSON returns the list of configured components.
 func (h *Handlers) GetComponentsJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
 	respondWithJSON(w, http.StatusOK, h.config.Components)
 }
 
@@ -93,10 +104,15 @@ func (h *Handlers) GetComponentInfoJSON(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusNotFound, "Component not found")
 		return
 	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
 	respondWithJSON(w, http.StatusOK, component)
 }
 
 // GetOutagesJSON retrieves outages for a specific component, aggregating sub-component outages for top-level components.
+// The results can be filtered by label, link type and start time. Each outage includes its status updates.
 func (h *Handlers) GetOutagesJSON(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	componentName := vars["componentName"]
@@ -108,13 +124,22 @@ func (h *Handlers) GetOutagesJSON(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusNotFound, "Component not found")
 		return
 	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+	query, message, valid := parseOutageQuery(r.URL.Query())
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
 	subComponents := []string{}
 	for _, subComponent := range component.Subcomponents {
 		subComponents = append(subComponents, subComponent.Name)
 	}
 
 	var outages []types.Outage
-	if err := h.db.Where("component_name IN ?", subComponents).Order("start_time DESC").Find(&outages).Error; err != nil {
+	if err := query.apply(h.db).Preload("Updates", orderOutageUpdates).Where("component_name IN ?", subComponents).Order("start_time DESC").Find(&outages).Error; err != nil {
 		logger.WithField("error", err).Error("Failed to query outages from database")
 		respondWithError(w, http.StatusInternalServerError, "Failed to get outages")
 		return
@@ -123,7 +148,7 @@ func (h *Handlers) GetOutagesJSON(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, outages)
 }
 
-// GetSubComponentOutagesJSON retrieves outages for a specific sub-component.
+// GetSubComponentOutagesJSON retrieves outages for a specific sub-component, accepting the same filters as GetOutagesJSON.
 func (h *Handlers) GetSubComponentOutagesJSON(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	componentName := vars["componentName"]
@@ -146,8 +171,17 @@ func (h *Handlers) GetSubComponentOutagesJSON(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+	query, message, valid := parseOutageQuery(r.URL.Query())
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
 	var outages []types.Outage
-	if err := h.db.Where("component_name = ?", subComponentName).Order("start_time DESC").Find(&outages).Error; err != nil {
+	if err := query.apply(h.db).Preload("Updates", orderOutageUpdates).Where("component_name = ?", subComponentName).Order("start_time DESC").Find(&outages).Error; err != nil {
 		logger.WithField("error", err).Error("Failed to query outages from database")
 		respondWithError(w, http.StatusInternalServerError, "Failed to get outages")
 		return
@@ -173,13 +207,57 @@ func (h *Handlers) CreateOutageJSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	onDuplicate := duplicatePolicy(r.URL.Query().Get("on_duplicate"))
+	if onDuplicate == "" {
+		onDuplicate = duplicateReject
+	}
+	if !onDuplicate.isValid() {
+		respondWithError(w, http.StatusBadRequest, "Invalid on_duplicate. Must be one of: reject, attach, create")
+		return
+	}
+
 	var outage types.Outage
 	if err := json.NewDecoder(r.Body).Decode(&outage); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	actions := []Action{ActionCreateOutage}
+	if outage.Severity == types.SeveritySuspected {
+		actions = []Action{ActionReportSuspectedOutage}
+	}
+	if outage.ConfirmedAt.Valid {
+		actions = append(actions, ActionConfirmOutage)
+	}
+	if outage.TriageNotes != nil {
+		actions = append(actions, ActionEditTriageNotes)
+	}
+	if !h.authorize(w, r, component, actions...) {
+		return
+	}
+	if !h.authorizeAutomation(w, r, component, subComponent, "create") {
+		return
+	}
+
+	identity := identityFromContext(r.Context())
 	outage.ComponentName = subComponentName
+	outage.CreatedBy = identity.User
+	outage.ResolvedBy = nil
+	if outage.EndTime.Valid {
+		outage.ResolvedBy = &identity.User
+	}
+	outage.ConfirmedBy = nil
+	if outage.ConfirmedAt.Valid {
+		outage.ConfirmedBy = &identity.User
+	}
+	outage.DeletedAt = gorm.DeletedAt{}
+	outage.DeletedBy = nil
+	outage.DeletionReason = nil
+	outage.Version = 1
+	outage.MergedInto = nil
+	outage.Reports = nil
+	outage.Updates = nil
+	outage.IncidentID = nil
 
 	if message, valid := h.validateOutage(&outage); !valid {
 		respondWithError(w, http.StatusBadRequest, message)
@@ -194,14 +272,30 @@ func (h *Handlers) CreateOutageJSON(w http.ResponseWriter, r *http.Request) {
 		"discovered_from": outage.DiscoveredFrom,
 	})
 
-	if err := h.db.Create(&outage).Error; err != nil {
+	var existing *types.Outage
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if onDuplicate != duplicateCreate && outageIsActive(&outage, time.Now()) {
+			var err error
+			if existing, err = lockActiveOutage(tx, subComponentName); err != nil || existing != nil {
+				return err
+			}
+		}
+		return tx.Create(&outage).Error
+	})
+	if err != nil {
 		logger.WithField("error", err).Error("Failed to create outage in database")
 		respondWithError(w, http.StatusInternalServerError, "Failed to create outage")
 		return
 	}
 
+	if existing != nil {
+		h.handleDuplicateOutage(w, logger, component, existing, &outage, onDuplicate)
+		return
+	}
+
 	logger.Infof("Successfully created outage: %d", outage.ID)
 
+	w.Header().Set("ETag", outageETag(&outage))
 	respondWithJSON(w, http.StatusCreated, outage)
 }
 
@@ -211,9 +305,61 @@ type UpdateOutageRequest struct {
 	StartTime   *time.Time `json:"start_time,omitempty"`
 	EndTime     *time.Time `json:"end_time,omitempty"`
 	Description *string    `json:"description,omitempty"`
-	ResolvedBy  *string    `json:"resolved_by,omitempty"`
 	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
 	TriageNotes *string    `json:"triage_notes,omitempty"`
+	// Labels and Links replace the existing labels and links of the outage when present.
+	Labels *map[string]string    `json:"labels,omitempty"`
+	Links  *[]types.ExternalLink `json:"links,omitempty"`
+}
+
+// requiredActions returns the actions performed by applying the update request.
+func (u *UpdateOutageRequest) requiredActions() []Action {
+	var actions []Action
+	if u.Severity != nil || u.StartTime != nil || u.EndTime != nil || u.Description != nil || u.Labels != nil || u.Links != nil {
+		actions = append(actions, ActionUpdateOutage)
+	}
+	if u.ConfirmedAt != nil {
+		actions = append(actions, ActionConfirmOutage)
+	}
+	if u.TriageNotes != nil {
+		actions = append(actions, ActionEditTriageNotes)
+	}
+	if len(actions) == 0 {
+		actions = append(actions, ActionUpdateOutage)
+	}
+	return actions
+}
+
+// columns returns the database columns changed by the update request, attributing resolution and confirmation to user.
+func (u *UpdateOutageRequest) columns(user string) map[string]interface{} {
+	columns := map[string]interface{}{}
+	if u.Severity != nil {
+		columns["severity"] = types.Severity(*u.Severity)
+	}
+	if u.StartTime != nil {
+		columns["start_time"] = *u.StartTime
+	}
+	if u.EndTime != nil {
+		columns["end_time"] = sql.NullTime{Time: *u.EndTime, Valid: true}
+		columns["resolved_by"] = user
+	}
+	if u.Description != nil {
+		columns["description"] = *u.Description
+	}
+	if u.ConfirmedAt != nil {
+		columns["confirmed_at"] = sql.NullTime{Time: *u.ConfirmedAt, Valid: true}
+		columns["confirmed_by"] = user
+	}
+	if u.TriageNotes != nil {
+		columns["triage_notes"] = *u.TriageNotes
+	}
+	if u.Labels != nil {
+		columns["labels"] = jsonColumn(*u.Labels)
+	}
+	if u.Links != nil {
+		columns["links"] = jsonColumn(*u.Links)
+	}
+	return columns
 }
 
 // UpdateOutageJSON updates an existing outage with the provided fields.
@@ -264,41 +410,59 @@ func (h *Handlers) UpdateOutageJSON(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-
-	if updateReq.Severity != nil {
-		if !types.IsValidSeverity(*updateReq.Severity) {
-			respondWithError(w, http.StatusBadRequest, "Invalid severity. Must be one of: Down, Degraded, Suspected")
-			return
-		}
-		outage.Severity = types.Severity(*updateReq.Severity)
-	}
-	if updateReq.StartTime != nil {
-		outage.StartTime = *updateReq.StartTime
+	if !h.authorize(w, r, component, updateReq.requiredActions()...) {
+		return
 	}
-	if updateReq.EndTime != nil {
-		outage.EndTime = sql.NullTime{Time: *updateReq.EndTime, Valid: true}
+	if updateReq.EndTime != nil && !h.authorizeAutomation(w, r, component, subComponent, "resolve") {
+		return
 	}
-	if updateReq.Description != nil {
-		outage.Description = *updateReq.Description
+	if !ifMatchSatisfied(r, outageETag(&outage)) {
+		respondWithPreconditionFailed(w)
+		return
 	}
-	if updateReq.ResolvedBy != nil {
-		outage.ResolvedBy = updateReq.ResolvedBy
+
+	if updateReq.Severity != nil && !types.IsValidSeverity(*updateReq.Severity) {
+		respondWithError(w, http.StatusBadRequest, "Invalid severity. Must be one of: Down, Degraded, Suspected")
+		return
 	}
-	if updateReq.ConfirmedAt != nil {
-		outage.ConfirmedAt = sql.NullTime{Time: *updateReq.ConfirmedAt, Valid: true}
+	if updateReq.Labels != nil {
+		if message, valid := validateLabels(*updateReq.Labels); !valid {
+			respondWithError(w, http.StatusBadRequest, message)
+			return
+		}
 	}
-	if updateReq.TriageNotes != nil {
-		outage.TriageNotes = updateReq.TriageNotes
+	if updateReq.Links != nil {
+		if message, valid := validateLinks(*updateReq.Links); !valid {
+			respondWithError(w, http.StatusBadRequest, message)
+			return
+		}
 	}
 
-	if err := h.db.Save(&outage).Error; err != nil {
-		logger.WithField("error", err).Error("Failed to update outage in database")
-		respondWithError(w, http.StatusInternalServerError, "Failed to update outage")
-		return
+	columns := updateReq.columns(identityFromContext(r.Context()).User)
+	if len(columns) > 0 {
+		columns["version"] = gorm.Expr("version + 1")
+		// Only write when nobody else changed the outage since it was read, so that concurrent edits are never lost
+		result := h.db.Model(&types.Outage{}).Where("id = ? AND version = ?", outage.ID, outage.Version).Updates(columns)
+		if result.Error != nil {
+			logger.WithField("error", result.Error).Error("Failed to update outage in database")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update outage")
+			return
+		}
+		if result.RowsAffected == 0 {
+			logger.Warn("Outage was modified concurrently")
+			respondWithPreconditionFailed(w)
+			return
+		}
+		if err := h.db.First(&outage, outage.ID).Error; err != nil {
+			logger.WithField("error", err).Error("Failed to query updated outage from database")
+			respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+			return
+		}
 	}
 
 	logger.Info("Successfully updated outage")
 
+	w.Header().Set("ETag", outageETag(&outage))
 	respondWithJSON(w, http.StatusOK, outage)
 }
 
@@ -327,8 +491,12 @@ func (h *Handlers) GetOutageJSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
 	var outage types.Outage
-	if err := h.db.Where("id = ? AND component_name = ?", outageId, subComponentName).First(&outage).Error; err != nil {
+	if err := h.db.Preload("Reports").Preload("Updates", orderOutageUpdates).Where("id = ? AND component_name = ?", outageId, subComponentName).First(&outage).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			respondWithError(w, http.StatusNotFound, "Outage not found")
 			return
@@ -339,10 +507,16 @@ func (h *Handlers) GetOutageJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Info("Successfully retrieved outage")
+	w.Header().Set("ETag", outageETag(&outage))
 	respondWithJSON(w, http.StatusOK, outage)
 }
 
-// DeleteOutage deletes an outage by ID for a specific sub-component.
+// DeleteOutageRequest represents the body of a request to delete an outage.
+type DeleteOutageRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DeleteOutage soft-deletes an outage by ID for a specific sub-component, recording who deleted it and why.
 func (h *Handlers) DeleteOutage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	componentName := vars["componentName"]
@@ -367,6 +541,21 @@ func (h *Handlers) DeleteOutage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.authorize(w, r, component, ActionDeleteOutage) {
+		return
+	}
+
+	var req DeleteOutageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		respondWithError(w, http.StatusBadRequest, "Reason is required")
+		return
+	}
+
 	var outage types.Outage
 	if err := h.db.Where("id = ? AND component_name = ?", outageId, subComponentName).First(&outage).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -378,13 +567,38 @@ func (h *Handlers) DeleteOutage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.db.Delete(&outage).Error; err != nil {
+	if !ifMatchSatisfied(r, outageETag(&outage)) {
+		respondWithPreconditionFailed(w)
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.Outage{}).Where("id = ? AND version = ?", outage.ID, outage.Version).Updates(map[string]interface{}{
+			"deleted_by":      identity.User,
+			"deletion_reason": reason,
+			"version":         gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOutageModified
+		}
+		return tx.Delete(&outage).Error
+	})
+	if errors.Is(err, errOutageModified) {
+		logger.Warn("Outage was modified concurrently")
+		respondWithPreconditionFailed(w)
+		return
+	}
+	if err != nil {
 		logger.WithField("error", err).Error("Failed to delete outage from database")
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete outage")
 		return
 	}
 
-	logger.Info("Successfully deleted outage")
+	logger.WithField("deleted_by", identity.User).Info("Successfully deleted outage")
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -411,8 +625,12 @@ func (h *Handlers) GetSubComponentStatusJSON(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
 	var outages []types.Outage
-	if err := h.db.Where("component_name = ? AND (end_time IS NULL OR end_time > ?)", subComponentName, time.Now()).Order("start_time DESC").Find(&outages).Error; err != nil {
+	if err := h.db.Preload("Updates", orderOutageUpdates).Where("component_name = ? AND (end_time IS NULL OR end_time > ?)", subComponentName, time.Now()).Order("start_time DESC").Find(&outages).Error; err != nil {
 		logger.WithField("error", err).Error("Failed to query active outages from database")
 		respondWithError(w, http.StatusInternalServerError, "Failed to get subcomponent status")
 		return
@@ -423,10 +641,18 @@ func (h *Handlers) GetSubComponentStatusJSON(w http.ResponseWriter, r *http.Requ
 		status = determineStatusFromSeverity(outages)
 	}
 
+	incidents, err := h.incidentReferences(outages)
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to query incidents from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get subcomponent status")
+		return
+	}
+
 	response := types.ComponentStatus{
 		ComponentName: fmt.Sprintf("%s/%s", componentName, subComponentName),
 		Status:        status,
 		ActiveOutages: outages,
+		Incidents:     incidents,
 	}
 	respondWithJSON(w, http.StatusOK, response)
 }
@@ -444,6 +670,10 @@ func (h *Handlers) GetComponentStatusJSON(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
 	response, err := h.getComponentStatus(component, logger)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to get component status")
@@ -454,6 +684,10 @@ func (h *Handlers) GetComponentStatusJSON(w http.ResponseWriter, r *http.Request
 
 // GetAllComponentsStatusJSON returns the status of all components
 func (h *Handlers) GetAllComponentsStatusJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
+
 	logger := h.logger
 
 	var allComponentStatuses []types.ComponentStatus
@@ -479,7 +713,7 @@ func (h *Handlers) getComponentStatus(component *types.Component, logger *logrus
 	}
 
 	var outages []types.Outage
-	if err := h.db.Where("component_name IN ? AND (end_time IS NULL OR end_time > ?)", subComponents, time.Now()).Order("start_time DESC").Find(&outages).Error; err != nil {
+	if err := h.db.Preload("Updates", orderOutageUpdates).Where("component_name IN ? AND (end_time IS NULL OR end_time > ?)", subComponents, time.Now()).Order("start_time DESC").Find(&outages).Error; err != nil {
 		logger.WithField("error", err).Error("Failed to query active outages from database")
 		return types.ComponentStatus{}, err
 	}
@@ -499,10 +733,17 @@ func (h *Handlers) getComponentStatus(component *types.Component, logger *logrus
 		status = determineStatusFromSeverity(outages)
 	}
 
+	incidents, err := h.incidentReferences(outages)
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to query incidents from database")
+		return types.ComponentStatus{}, err
+	}
+
 	return types.ComponentStatus{
 		ComponentName: component.Name,
 		Status:        status,
 		ActiveOutages: outages,
+		Incidents:     incidents,
 	}, nil
 }
 
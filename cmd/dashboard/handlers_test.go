@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"ship-status-dash/pkg/types"
 
@@ -130,3 +132,55 @@ func TestGetComponent(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateOutageRequest_Columns(t *testing.T) {
+	severity := string(types.SeverityDegraded)
+	notes := "root cause found"
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	labels := map[string]string{"cause": "github"}
+	noLinks := []types.ExternalLink{}
+
+	tests := []struct {
+		name     string
+		request  UpdateOutageRequest
+		expected map[string]interface{}
+	}{
+		{
+			name:     "empty request changes nothing",
+			request:  UpdateOutageRequest{},
+			expected: map[string]interface{}{},
+		},
+		{
+			name:    "only provided fields are written",
+			request: UpdateOutageRequest{Severity: &severity, TriageNotes: &notes},
+			expected: map[string]interface{}{
+				"severity":     types.SeverityDegraded,
+				"triage_notes": notes,
+			},
+		},
+		{
+			name:    "resolution and confirmation are attributed to the user",
+			request: UpdateOutageRequest{EndTime: &now, ConfirmedAt: &now},
+			expected: map[string]interface{}{
+				"end_time":     sql.NullTime{Time: now, Valid: true},
+				"resolved_by":  "jdoe",
+				"confirmed_at": sql.NullTime{Time: now, Valid: true},
+				"confirmed_by": "jdoe",
+			},
+		},
+		{
+			name:    "labels are replaced and empty links are cleared",
+			request: UpdateOutageRequest{Labels: &labels, Links: &noLinks},
+			expected: map[string]interface{}{
+				"labels": `{"cause":"github"}`,
+				"links":  nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.request.columns("jdoe"))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyCleanupPeriod  = time.Hour
+	idempotencyInsertAttempts = 2
+	// idempotencyReservationTTL is how long a key may stay reserved by a request in progress. Requests are
+	// expected to finish well within it, so older reservations were left behind by a process that died mid-request.
+	idempotencyReservationTTL = 5 * time.Minute
+)
+
+// replayedResponseHeaders are the response headers stored with an idempotency key and sent again on replay.
+var replayedResponseHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// requestFingerprint identifies the request an idempotency key was first used with. The query string is part of
+// it, since options such as on_duplicate change what the request does.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// checkIdempotencyKey decides how to answer a request whose key was already used. It returns zero when the stored
+// response should be replayed, and otherwise the status code and message to reject the request with.
+func checkIdempotencyKey(existing *types.IdempotencyKey, fingerprint string) (int, string) {
+	if existing.Fingerprint != fingerprint {
+		return http.StatusUnprocessableEntity, "Idempotency-Key has already been used with a different request"
+	}
+	if existing.StatusCode == 0 {
+		return http.StatusConflict, "A request with this Idempotency-Key is still being processed"
+	}
+	return 0, ""
+}
+
+// idempotencyKeyExpired reports whether a stored key can be discarded, either because it is past its expiry or
+// because it was reserved by a request that was abandoned.
+func idempotencyKeyExpired(existing *types.IdempotencyKey, now time.Time) bool {
+	if !existing.ExpiresAt.After(now) {
+		return true
+	}
+	return existing.StatusCode == 0 && now.Sub(existing.CreatedAt) > idempotencyReservationTTL
+}
+
+// responseCapture passes the response through while keeping a copy for storage.
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// reserveIdempotencyKey claims the key for the caller, and returns the existing record if the key was already used.
+// Expired records and abandoned reservations are discarded so that the key can be reused.
+func (s *Server) reserveIdempotencyKey(record *types.IdempotencyKey) (*types.IdempotencyKey, error) {
+	for attempt := 0; attempt < idempotencyInsertAttempts; attempt++ {
+		result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing types.IdempotencyKey
+		err := s.db.Where("user_name = ? AND idempotency_key = ?", record.User, record.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !idempotencyKeyExpired(&existing, time.Now()) {
+			return &existing, nil
+		}
+		if err := s.db.Delete(&existing).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("failed to reserve idempotency key")
+}
+
+// idempotencyMiddleware makes mutating requests with an Idempotency-Key header safe to retry: the first response is
+// stored, and later requests with the same key and body are answered with it instead of being applied again.
+func (s *Server) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		identity := identityFromContext(r.Context())
+		if key == "" || !isMutatingMethod(r.Method) || identity == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		body, ok := readRequestBody(w, r)
+		if !ok {
+			return
+		}
+
+		logger := s.logger.WithFields(logrus.Fields{
+			"user":            identity.User,
+			"idempotency_key": key,
+			"path":            r.URL.Path,
+		})
+
+		record := &types.IdempotencyKey{
+			User:        identity.User,
+			Key:         key,
+			Fingerprint: requestFingerprint(r, body),
+			ExpiresAt:   time.Now().Add(s.idempotencyKeyTTL),
+		}
+		existing, err := s.reserveIdempotencyKey(record)
+		if err != nil {
+			logger.WithField("error", err).Error("Failed to reserve idempotency key")
+			respondWithError(w, http.StatusInternalServerError, "Failed to process Idempotency-Key")
+			return
+		}
+		if existing != nil {
+			if status, message := checkIdempotencyKey(existing, record.Fingerprint); status != 0 {
+				respondWithError(w, status, message)
+				return
+			}
+			logger.Info("Replaying response for idempotency key")
+			for name, value := range existing.ResponseHeaders {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(existing.StatusCode)
+			w.Write(existing.ResponseBody)
+			return
+		}
+
+		// The key is released unless a response is stored for it, including when the handler panics, so that the
+		// request can be retried
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := s.db.Delete(record).Error; err != nil {
+				logger.WithField("error", err).Error("Failed to release idempotency key")
+			}
+		}()
+
+		capture := &responseCapture{ResponseWriter: w}
+		next.ServeHTTP(capture, r)
+		if capture.status == 0 {
+			capture.status = http.StatusOK
+		}
+
+		// Server errors are likely transient, so the key is released to allow the request to be retried
+		if capture.status >= http.StatusInternalServerError {
+			return
+		}
+
+		headers := map[string]string{}
+		for _, name := range replayedResponseHeaders {
+			if value := capture.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := s.db.Model(record).Updates(types.IdempotencyKey{
+			StatusCode:      capture.status,
+			ResponseHeaders: headers,
+			ResponseBody:    capture.body.Bytes(),
+		}).Error; err != nil {
+			logger.WithField("error", err).Error("Failed to store response for idempotency key")
+			return
+		}
+		stored = true
+	})
+}
+
+// cleanupExpiredIdempotencyKeys periodically removes idempotency keys that can no longer be replayed.
+func (s *Server) cleanupExpiredIdempotencyKeys() {
+	ticker := time.NewTicker(idempotencyCleanupPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		result := s.db.Where("expires_at < ?", time.Now()).Delete(&types.IdempotencyKey{})
+		if result.Error != nil {
+			s.logger.WithField("error", result.Error).Error("Failed to remove expired idempotency keys")
+			continue
+		}
+		if result.RowsAffected > 0 {
+			s.logger.Infof("Removed %d expired idempotency keys", result.RowsAffected)
+		}
+	}
+}
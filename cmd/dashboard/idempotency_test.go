@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	fingerprint := func(method, path, body string) string {
+		return requestFingerprint(httptest.NewRequest(method, path, nil), []byte(body))
+	}
+
+	original := fingerprint("POST", "/api/components/Prow/Tide/outages", `{"severity":"Down"}`)
+	assert.Equal(t, original, fingerprint("POST", "/api/components/Prow/Tide/outages", `{"severity":"Down"}`))
+	assert.NotEqual(t, original, fingerprint("POST", "/api/components/Prow/Tide/outages", `{"severity":"Degraded"}`))
+	assert.NotEqual(t, original, fingerprint("POST", "/api/components/Prow/Deck/outages", `{"severity":"Down"}`))
+	assert.NotEqual(t, original, fingerprint("PATCH", "/api/components/Prow/Tide/outages", `{"severity":"Down"}`))
+	assert.NotEqual(t, original, fingerprint("POST", "/api/components/Prow/Tide/outages?on_duplicate=create", `{"severity":"Down"}`))
+	assert.NotEqual(t,
+		fingerprint("POST", "/api/components/Prow/Tide/outages?on_duplicate=reject", `{"severity":"Down"}`),
+		fingerprint("POST", "/api/components/Prow/Tide/outages?on_duplicate=create", `{"severity":"Down"}`))
+}
+
+func TestCheckIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		existing       types.IdempotencyKey
+		expectedStatus int
+	}{
+		{
+			name:           "completed request with the same body is replayed",
+			existing:       types.IdempotencyKey{Fingerprint: "abc", StatusCode: http.StatusCreated},
+			expectedStatus: 0,
+		},
+		{
+			name:           "different body",
+			existing:       types.IdempotencyKey{Fingerprint: "def", StatusCode: http.StatusCreated},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "original request still in progress",
+			existing:       types.IdempotencyKey{Fingerprint: "abc"},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "different body while in progress",
+			existing:       types.IdempotencyKey{Fingerprint: "def"},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _ := checkIdempotencyKey(&tt.existing, "abc")
+			assert.Equal(t, tt.expectedStatus, status)
+		})
+	}
+}
+
+func TestIdempotencyKeyExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		existing types.IdempotencyKey
+		expected bool
+	}{
+		{
+			name:     "completed request within its expiry",
+			existing: types.IdempotencyKey{StatusCode: http.StatusCreated, CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:     "completed request past its expiry",
+			existing: types.IdempotencyKey{StatusCode: http.StatusCreated, CreatedAt: now.Add(-25 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+			expected: true,
+		},
+		{
+			name:     "request recently started",
+			existing: types.IdempotencyKey{CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:     "request abandoned mid-flight",
+			existing: types.IdempotencyKey{CreatedAt: now.Add(-idempotencyReservationTTL - time.Second), ExpiresAt: now.Add(time.Hour)},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, idempotencyKeyExpired(&tt.existing, now))
+		})
+	}
+}
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CreateIncidentRequest represents the body of a request to open an incident.
+type CreateIncidentRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status,omitempty"`
+	Commander   string `json:"commander,omitempty"`
+	// OutageIDs are linked to the incident when it is created.
+	OutageIDs []uint `json:"outage_ids,omitempty"`
+}
+
+func (c *CreateIncidentRequest) validate() (string, bool) {
+	if strings.TrimSpace(c.Title) == "" {
+		return "Title is required", false
+	}
+	if c.Status != "" && !types.IsValidIncidentStatus(c.Status) {
+		return "Invalid status. Must be one of: Investigating, Identified, Monitoring, Resolved", false
+	}
+	return "", true
+}
+
+// UpdateIncidentRequest represents the fields of an incident that can be updated in a PATCH request.
+type UpdateIncidentRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Status      *string `json:"status,omitempty"`
+	Commander   *string `json:"commander,omitempty"`
+}
+
+func (u *UpdateIncidentRequest) validate() (string, bool) {
+	if u.Title != nil && strings.TrimSpace(*u.Title) == "" {
+		return "Title cannot be empty", false
+	}
+	if u.Status != nil && !types.IsValidIncidentStatus(*u.Status) {
+		return "Invalid status. Must be one of: Investigating, Identified, Monitoring, Resolved", false
+	}
+	return "", true
+}
+
+// changes returns the columns changed by the update request, and the timeline events describing the changes.
+func (u *UpdateIncidentRequest) changes(incident *types.Incident, now time.Time) (map[string]interface{}, []types.IncidentEvent) {
+	columns := map[string]interface{}{}
+	var events []types.IncidentEvent
+	event := func(kind types.IncidentEventKind, format string, args ...interface{}) {
+		events = append(events, types.IncidentEvent{IncidentID: incident.ID, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if u.Title != nil && *u.Title != incident.Title {
+		columns["title"] = *u.Title
+		event(types.IncidentEventUpdated, "Title changed from %q to %q", incident.Title, *u.Title)
+	}
+	if u.Description != nil && *u.Description != incident.Description {
+		columns["description"] = *u.Description
+		event(types.IncidentEventUpdated, "Description updated")
+	}
+	if u.Status != nil && types.IncidentStatus(*u.Status) != incident.Status {
+		status := types.IncidentStatus(*u.Status)
+		columns["status"] = status
+		if status == types.IncidentStatusResolved {
+			columns["resolved_at"] = sql.NullTime{Time: now, Valid: true}
+		} else if incident.Status == types.IncidentStatusResolved {
+			columns["resolved_at"] = sql.NullTime{}
+		}
+		event(types.IncidentEventStatusChanged, "Status changed from %s to %s", incident.Status, status)
+	}
+	if u.Commander != nil && *u.Commander != incident.Commander {
+		columns["commander"] = *u.Commander
+		switch {
+		case incident.Commander == "":
+			event(types.IncidentEventCommanderChange, "Commander set to %s", *u.Commander)
+		case *u.Commander == "":
+			event(types.IncidentEventCommanderChange, "Commander %s stepped down", incident.Commander)
+		default:
+			event(types.IncidentEventCommanderChange, "Commander changed from %s to %s", incident.Commander, *u.Commander)
+		}
+	}
+	return columns, events
+}
+
+// IncidentNoteRequest represents the body of a request to add a note to an incident's timeline.
+type IncidentNoteRequest struct {
+	Message string `json:"message"`
+}
+
+// LinkOutageRequest represents the body of a request to link an outage to an incident.
+type LinkOutageRequest struct {
+	OutageID uint `json:"outage_id"`
+}
+
+// errOutageAlreadyLinked aborts linking outages when one of them was linked to another incident after it was read.
+var errOutageAlreadyLinked = errors.New("outage is already linked to another incident")
+
+// linkedElsewhere returns the first of the outages that is linked to an incident other than the given one.
+// Pass 0 for an incident that does not exist yet.
+func linkedElsewhere(outages []types.Outage, incidentID uint) *types.Outage {
+	for i := range outages {
+		if outages[i].IncidentID != nil && *outages[i].IncidentID != incidentID {
+			return &outages[i]
+		}
+	}
+	return nil
+}
+
+// linkOutages links the outages to the incident. Only outages that are not linked to an incident are updated, so that
+// a concurrent request cannot move an outage from one incident to another; errOutageAlreadyLinked is returned then.
+func linkOutages(tx *gorm.DB, outages []types.Outage, incidentID uint) error {
+	outageIDs := make([]uint, 0, len(outages))
+	for _, outage := range outages {
+		outageIDs = append(outageIDs, outage.ID)
+	}
+	result := tx.Model(&types.Outage{}).Where("id IN ? AND incident_id IS NULL", outageIDs).Updates(map[string]interface{}{
+		"incident_id": incidentID,
+		"version":     gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(outageIDs)) {
+		return errOutageAlreadyLinked
+	}
+	return nil
+}
+
+// componentForSubComponent returns the component that the named sub-component belongs to.
+func (h *Handlers) componentForSubComponent(subComponentName string) *types.Component {
+	for i := range h.config.Components {
+		if h.config.Components[i].GetSubComponent(subComponentName) != nil {
+			return &h.config.Components[i]
+		}
+	}
+	return nil
+}
+
+// authorizeOutages is like authorize, but requires the caller to manage incidents for the component of every outage,
+// so that linking an outage to an incident is not open to the owners of unrelated components.
+func (h *Handlers) authorizeOutages(w http.ResponseWriter, r *http.Request, outages []types.Outage) bool {
+	for _, outage := range outages {
+		if !h.authorize(w, r, h.componentForSubComponent(outage.ComponentName), ActionManageIncidents) {
+			return false
+		}
+	}
+	return true
+}
+
+// affectedComponents returns the sorted names of the components that the outages belong to.
+func (h *Handlers) affectedComponents(outages []types.Outage) []string {
+	components := []string{}
+	for _, outage := range outages {
+		component := h.componentForSubComponent(outage.ComponentName)
+		if component != nil && !slices.Contains(components, component.Name) {
+			components = append(components, component.Name)
+		}
+	}
+	slices.Sort(components)
+	return components
+}
+
+// incidentReferences returns the incidents that the outages are linked to.
+func (h *Handlers) incidentReferences(outages []types.Outage) ([]types.IncidentReference, error) {
+	var incidentIDs []uint
+	for _, outage := range outages {
+		if outage.IncidentID != nil && !slices.Contains(incidentIDs, *outage.IncidentID) {
+			incidentIDs = append(incidentIDs, *outage.IncidentID)
+		}
+	}
+	if len(incidentIDs) == 0 {
+		return nil, nil
+	}
+
+	var incidents []types.Incident
+	if err := h.db.Where("id IN ?", incidentIDs).Order("id").Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	references := make([]types.IncidentReference, 0, len(incidents))
+	for _, incident := range incidents {
+		references = append(references, types.IncidentReference{ID: incident.ID, Title: incident.Title, Status: incident.Status})
+	}
+	return references, nil
+}
+
+// loadIncident retrieves an incident with its outages and timeline.
+func (h *Handlers) loadIncident(incidentID string) (types.Incident, error) {
+	var incident types.Incident
+	err := h.db.
+		Preload("Outages", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).
+		Preload("Timeline", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		First(&incident, "id = ?", incidentID).Error
+	if err != nil {
+		return incident, err
+	}
+	incident.AffectedComponents = h.affectedComponents(incident.Outages)
+	return incident, nil
+}
+
+// respondWithIncident responds with the current state of the incident.
+func (h *Handlers) respondWithIncident(w http.ResponseWriter, logger *logrus.Entry, statusCode int, incidentID string) {
+	incident, err := h.loadIncident(incidentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Incident not found")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query incident from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get incident")
+		return
+	}
+	respondWithJSON(w, statusCode, incident)
+}
+
+// findIncident looks up the incident named in the request and responds with an error if it cannot be found.
+func (h *Handlers) findIncident(w http.ResponseWriter, logger *logrus.Entry, incidentID string) (*types.Incident, bool) {
+	var incident types.Incident
+	if err := h.db.First(&incident, "id = ?", incidentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Incident not found")
+			return nil, false
+		}
+		logger.WithField("error", err).Error("Failed to query incident from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get incident")
+		return nil, false
+	}
+	return &incident, true
+}
+
+// GetIncidentsJSON lists incidents, most recent first. The status query parameter filters by status, and
+// active=true limits the list to unresolved incidents.
+func (h *Handlers) GetIncidentsJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
+
+	query := h.db.Preload("Outages")
+	if status := r.URL.Query().Get("status"); status != "" {
+		if !types.IsValidIncidentStatus(status) {
+			respondWithError(w, http.StatusBadRequest, "Invalid status. Must be one of: Investigating, Identified, Monitoring, Resolved")
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+	if r.URL.Query().Get("active") == "true" {
+		query = query.Where("status <> ?", types.IncidentStatusResolved)
+	}
+
+	var incidents []types.Incident
+	if err := query.Order("created_at DESC").Find(&incidents).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to query incidents from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get incidents")
+		return
+	}
+	for i := range incidents {
+		incidents[i].AffectedComponents = h.affectedComponents(incidents[i].Outages)
+	}
+
+	respondWithJSON(w, http.StatusOK, incidents)
+}
+
+// GetIncidentJSON retrieves an incident with its outages, affected components and timeline.
+func (h *Handlers) GetIncidentJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
+
+	incidentID := mux.Vars(r)["incidentId"]
+	h.respondWithIncident(w, h.logger.WithField("incident_id", incidentID), http.StatusOK, incidentID)
+}
+
+// CreateIncidentJSON opens an incident, optionally linking existing outages to it.
+func (h *Handlers) CreateIncidentJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAnyComponent(w, r, ActionManageIncidents) {
+		return
+	}
+
+	var req CreateIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	incident := types.Incident{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      types.IncidentStatusInvestigating,
+		Commander:   req.Commander,
+		CreatedBy:   identity.User,
+	}
+	if req.Status != "" {
+		incident.Status = types.IncidentStatus(req.Status)
+	}
+	if incident.Status == types.IncidentStatusResolved {
+		incident.ResolvedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"title":      incident.Title,
+		"created_by": incident.CreatedBy,
+	})
+
+	var outages []types.Outage
+	if len(req.OutageIDs) > 0 {
+		if err := h.db.Where("id IN ?", req.OutageIDs).Find(&outages).Error; err != nil {
+			logger.WithField("error", err).Error("Failed to query outages from database")
+			respondWithError(w, http.StatusInternalServerError, "Failed to get outages")
+			return
+		}
+		var missing []uint
+		for _, id := range req.OutageIDs {
+			if !slices.ContainsFunc(outages, func(outage types.Outage) bool { return outage.ID == id }) {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			respondWithError(w, http.StatusNotFound, fmt.Sprintf("Outages not found: %v", missing))
+			return
+		}
+		if !h.authorizeOutages(w, r, outages) {
+			return
+		}
+		if linked := linkedElsewhere(outages, 0); linked != nil {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Outage %d is already linked to incident %d", linked.ID, *linked.IncidentID))
+			return
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&incident).Error; err != nil {
+			return err
+		}
+		events := []types.IncidentEvent{{
+			IncidentID: incident.ID,
+			Kind:       types.IncidentEventCreated,
+			Message:    fmt.Sprintf("Incident opened with status %s", incident.Status),
+			Author:     identity.User,
+		}}
+
+		if len(outages) > 0 {
+			if err := linkOutages(tx, outages, incident.ID); err != nil {
+				return err
+			}
+			for _, outage := range outages {
+				events = append(events, types.IncidentEvent{
+					IncidentID: incident.ID,
+					Kind:       types.IncidentEventOutageLinked,
+					Message:    fmt.Sprintf("Linked outage %d of %s", outage.ID, outage.ComponentName),
+					Author:     identity.User,
+				})
+			}
+		}
+		return tx.Create(&events).Error
+	})
+	if errors.Is(err, errOutageAlreadyLinked) {
+		respondWithError(w, http.StatusConflict, "An outage was linked to another incident in the meantime")
+		return
+	}
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to create incident in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create incident")
+		return
+	}
+
+	logger.Infof("Successfully created incident: %d", incident.ID)
+	h.respondWithIncident(w, logger, http.StatusCreated, fmt.Sprint(incident.ID))
+}
+
+// UpdateIncidentJSON updates an incident, recording each change on its timeline.
+func (h *Handlers) UpdateIncidentJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAnyComponent(w, r, ActionManageIncidents) {
+		return
+	}
+
+	incidentID := mux.Vars(r)["incidentId"]
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"incident_id": incidentID,
+		"user":        identity.User,
+	})
+
+	var req UpdateIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	incident, found := h.findIncident(w, logger, incidentID)
+	if !found {
+		return
+	}
+
+	columns, events := req.changes(incident, time.Now())
+	if len(columns) > 0 {
+		for i := range events {
+			events[i].Author = identity.User
+		}
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(incident).Updates(columns).Error; err != nil {
+				return err
+			}
+			return tx.Create(&events).Error
+		})
+		if err != nil {
+			logger.WithField("error", err).Error("Failed to update incident in database")
+			respondWithError(w, http.StatusInternalServerError, "Failed to update incident")
+			return
+		}
+		logger.Info("Successfully updated incident")
+	}
+
+	h.respondWithIncident(w, logger, http.StatusOK, incidentID)
+}
+
+// DeleteIncident deletes an incident and unlinks its outages.
+func (h *Handlers) DeleteIncident(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionDeleteIncident) {
+		return
+	}
+
+	incidentID := mux.Vars(r)["incidentId"]
+	logger := h.logger.WithFields(logrus.Fields{
+		"incident_id": incidentID,
+		"user":        identityFromContext(r.Context()).User,
+	})
+
+	incident, found := h.findIncident(w, logger, incidentID)
+	if !found {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.Outage{}).Where("incident_id = ?", incident.ID).Updates(map[string]interface{}{
+			"incident_id": nil,
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(incident).Error
+	})
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to delete incident from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete incident")
+		return
+	}
+
+	logger.Info("Successfully deleted incident")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddIncidentNoteJSON adds a note to the timeline of an incident.
+func (h *Handlers) AddIncidentNoteJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAnyComponent(w, r, ActionManageIncidents) {
+		return
+	}
+
+	incidentID := mux.Vars(r)["incidentId"]
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"incident_id": incidentID,
+		"user":        identity.User,
+	})
+
+	var req IncidentNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		respondWithError(w, http.StatusBadRequest, "Message is required")
+		return
+	}
+
+	incident, found := h.findIncident(w, logger, incidentID)
+	if !found {
+		return
+	}
+
+	event := types.IncidentEvent{
+		IncidentID: incident.ID,
+		Kind:       types.IncidentEventNote,
+		Message:    req.Message,
+		Author:     identity.User,
+	}
+	if err := h.db.Create(&event).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to add note to incident in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to add note to incident")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, event)
+}
+
+// LinkOutageJSON links an outage to an incident, provided the caller manages incidents for its component.
+func (h *Handlers) LinkOutageJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAnyComponent(w, r, ActionManageIncidents) {
+		return
+	}
+
+	incidentID := mux.Vars(r)["incidentId"]
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"incident_id": incidentID,
+		"user":        identity.User,
+	})
+
+	var req LinkOutageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.OutageID == 0 {
+		respondWithError(w, http.StatusBadRequest, "OutageID is required")
+		return
+	}
+	logger = logger.WithField("outage_id", req.OutageID)
+
+	incident, found := h.findIncident(w, logger, incidentID)
+	if !found {
+		return
+	}
+
+	var outage types.Outage
+	if err := h.db.First(&outage, req.OutageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Outage not found")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return
+	}
+	if !h.authorizeOutages(w, r, []types.Outage{outage}) {
+		return
+	}
+	if linked := linkedElsewhere([]types.Outage{outage}, incident.ID); linked != nil {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Outage %d is already linked to incident %d", linked.ID, *linked.IncidentID))
+		return
+	}
+
+	if outage.IncidentID == nil {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := linkOutages(tx, []types.Outage{outage}, incident.ID); err != nil {
+				return err
+			}
+			return tx.Create(&types.IncidentEvent{
+				IncidentID: incident.ID,
+				Kind:       types.IncidentEventOutageLinked,
+				Message:    fmt.Sprintf("Linked outage %d of %s", outage.ID, outage.ComponentName),
+				Author:     identity.User,
+			}).Error
+		})
+		if errors.Is(err, errOutageAlreadyLinked) {
+			respondWithError(w, http.StatusConflict, "The outage was linked to another incident in the meantime")
+			return
+		}
+		if err != nil {
+			logger.WithField("error", err).Error("Failed to link outage to incident in database")
+			respondWithError(w, http.StatusInternalServerError, "Failed to link outage to incident")
+			return
+		}
+		logger.Info("Successfully linked outage to incident")
+	}
+
+	h.respondWithIncident(w, logger, http.StatusOK, incidentID)
+}
+
+// UnlinkOutage removes an outage from an incident.
+func (h *Handlers) UnlinkOutage(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAnyComponent(w, r, ActionManageIncidents) {
+		return
+	}
+
+	vars := mux.Vars(r)
+	incidentID := vars["incidentId"]
+	outageID := vars["outageId"]
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"incident_id": incidentID,
+		"outage_id":   outageID,
+		"user":        identity.User,
+	})
+
+	incident, found := h.findIncident(w, logger, incidentID)
+	if !found {
+		return
+	}
+
+	var outage types.Outage
+	if err := h.db.Where("id = ? AND incident_id = ?", outageID, incident.ID).First(&outage).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Outage is not linked to the incident")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return
+	}
+	if !h.authorizeOutages(w, r, []types.Outage{outage}) {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.Outage{}).Where("id = ?", outage.ID).Updates(map[string]interface{}{
+			"incident_id": nil,
+			"version":     gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&types.IncidentEvent{
+			IncidentID: incident.ID,
+			Kind:       types.IncidentEventOutageUnlinked,
+			Message:    fmt.Sprintf("Unlinked outage %d of %s", outage.ID, outage.ComponentName),
+			Author:     identity.User,
+		}).Error
+	})
+	if err != nil {
+		logger.WithField("error", err).Error("Failed to unlink outage from incident in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to unlink outage from incident")
+		return
+	}
+
+	logger.Info("Successfully unlinked outage from incident")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCreateIncidentRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         CreateIncidentRequest
+		expectedMessage string
+	}{
+		{
+			name:    "title only",
+			request: CreateIncidentRequest{Title: "Registry unavailable"},
+		},
+		{
+			name:    "with status",
+			request: CreateIncidentRequest{Title: "Registry unavailable", Status: "Identified"},
+		},
+		{
+			name:            "missing title",
+			request:         CreateIncidentRequest{Title: "  "},
+			expectedMessage: "Title is required",
+		},
+		{
+			name:            "invalid status",
+			request:         CreateIncidentRequest{Title: "Registry unavailable", Status: "Fixed"},
+			expectedMessage: "Invalid status. Must be one of: Investigating, Identified, Monitoring, Resolved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := tt.request.validate()
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
+
+func TestUpdateIncidentRequest_Changes(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name            string
+		incident        types.Incident
+		request         UpdateIncidentRequest
+		expectedColumns map[string]interface{}
+		expectedEvents  []types.IncidentEvent
+	}{
+		{
+			name:            "no changes",
+			incident:        types.Incident{Title: "Registry unavailable", Status: types.IncidentStatusInvestigating},
+			request:         UpdateIncidentRequest{Title: stringPtr("Registry unavailable")},
+			expectedColumns: map[string]interface{}{},
+		},
+		{
+			name:            "resolve",
+			incident:        types.Incident{Status: types.IncidentStatusMonitoring},
+			request:         UpdateIncidentRequest{Status: stringPtr("Resolved")},
+			expectedColumns: map[string]interface{}{"status": types.IncidentStatusResolved, "resolved_at": sql.NullTime{Time: now, Valid: true}},
+			expectedEvents: []types.IncidentEvent{
+				{Kind: types.IncidentEventStatusChanged, Message: "Status changed from Monitoring to Resolved"},
+			},
+		},
+		{
+			name:            "reopen",
+			incident:        types.Incident{Status: types.IncidentStatusResolved, ResolvedAt: sql.NullTime{Time: now, Valid: true}},
+			request:         UpdateIncidentRequest{Status: stringPtr("Investigating")},
+			expectedColumns: map[string]interface{}{"status": types.IncidentStatusInvestigating, "resolved_at": sql.NullTime{}},
+			expectedEvents: []types.IncidentEvent{
+				{Kind: types.IncidentEventStatusChanged, Message: "Status changed from Resolved to Investigating"},
+			},
+		},
+		{
+			name:            "commander assigned",
+			incident:        types.Incident{Status: types.IncidentStatusInvestigating},
+			request:         UpdateIncidentRequest{Commander: stringPtr("alice")},
+			expectedColumns: map[string]interface{}{"commander": "alice"},
+			expectedEvents: []types.IncidentEvent{
+				{Kind: types.IncidentEventCommanderChange, Message: "Commander set to alice"},
+			},
+		},
+		{
+			name:            "commander handed over",
+			incident:        types.Incident{Status: types.IncidentStatusInvestigating, Commander: "alice"},
+			request:         UpdateIncidentRequest{Commander: stringPtr("bob")},
+			expectedColumns: map[string]interface{}{"commander": "bob"},
+			expectedEvents: []types.IncidentEvent{
+				{Kind: types.IncidentEventCommanderChange, Message: "Commander changed from alice to bob"},
+			},
+		},
+		{
+			name:            "title and description",
+			incident:        types.Incident{Model: gorm.Model{ID: 7}, Title: "Registry", Description: "Pulls fail"},
+			request:         UpdateIncidentRequest{Title: stringPtr("Registry unavailable"), Description: stringPtr("Pushes and pulls fail")},
+			expectedColumns: map[string]interface{}{"title": "Registry unavailable", "description": "Pushes and pulls fail"},
+			expectedEvents: []types.IncidentEvent{
+				{IncidentID: 7, Kind: types.IncidentEventUpdated, Message: `Title changed from "Registry" to "Registry unavailable"`},
+				{IncidentID: 7, Kind: types.IncidentEventUpdated, Message: "Description updated"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, events := tt.request.changes(&tt.incident, now)
+			assert.Equal(t, tt.expectedColumns, columns)
+			assert.Equal(t, tt.expectedEvents, events)
+		})
+	}
+}
+
+func TestAffectedComponents(t *testing.T) {
+	handlers := &Handlers{config: &types.Config{Components: []types.Component{
+		{Name: "Prow", Subcomponents: []types.SubComponent{{Name: "Tide"}, {Name: "Deck"}}},
+		{Name: "Build Farm", Subcomponents: []types.SubComponent{{Name: "build01"}}},
+	}}}
+
+	outages := []types.Outage{
+		{ComponentName: "Tide"},
+		{ComponentName: "build01"},
+		{ComponentName: "Deck"},
+		{ComponentName: "removed-sub-component"},
+	}
+	assert.Equal(t, []string{"Build Farm", "Prow"}, handlers.affectedComponents(outages))
+	assert.Equal(t, []string{}, handlers.affectedComponents(nil))
+}
+
+func TestLinkedElsewhere(t *testing.T) {
+	uintPtr := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name       string
+		outages    []types.Outage
+		incidentID uint
+		expected   uint
+	}{
+		{name: "no outages", incidentID: 1},
+		{name: "unlinked outages", outages: []types.Outage{{Model: gorm.Model{ID: 1}}, {Model: gorm.Model{ID: 2}}}, incidentID: 1},
+		{name: "outage already linked to the incident", outages: []types.Outage{{Model: gorm.Model{ID: 1}, IncidentID: uintPtr(1)}}, incidentID: 1},
+		{name: "outage linked to another incident", outages: []types.Outage{{Model: gorm.Model{ID: 1}}, {Model: gorm.Model{ID: 2}, IncidentID: uintPtr(3)}}, incidentID: 1, expected: 2},
+		{name: "new incident", outages: []types.Outage{{Model: gorm.Model{ID: 1}, IncidentID: uintPtr(1)}}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linked := linkedElsewhere(tt.outages, tt.incidentID)
+			if tt.expected == 0 {
+				assert.Nil(t, linked)
+				return
+			}
+			require.NotNil(t, linked)
+			assert.Equal(t, tt.expected, linked.ID)
+		})
+	}
+}
+
+func TestAuthorizeOutages(t *testing.T) {
+	handlers := &Handlers{
+		config: &types.Config{
+			DefaultRole: types.RoleReporter,
+			Components: []types.Component{
+				{Name: "Prow", Subcomponents: []types.SubComponent{{Name: "Tide"}}, Owners: []types.Owner{{RoverGroup: "dptp"}}},
+				{Name: "Build Farm", Subcomponents: []types.SubComponent{{Name: "build01"}}, Owners: []types.Owner{{RoverGroup: "build-farm"}}},
+			},
+			RoleBindings: []types.RoleBinding{{Role: types.RoleAdmin, Groups: []string{"ship-admins"}}},
+		},
+		logger: logrus.New(),
+	}
+	prowOwner := &Identity{User: "jdoe", Groups: []string{"dptp"}}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		outages  []types.Outage
+		expected int
+	}{
+		{name: "owner of the outage's component", identity: prowOwner, outages: []types.Outage{{ComponentName: "Tide"}}, expected: http.StatusOK},
+		{name: "owner of another component", identity: prowOwner, outages: []types.Outage{{ComponentName: "build01"}}, expected: http.StatusForbidden},
+		{name: "one outage of another component", identity: prowOwner, outages: []types.Outage{{ComponentName: "Tide"}, {ComponentName: "build01"}}, expected: http.StatusForbidden},
+		{name: "outage of a removed sub-component", identity: prowOwner, outages: []types.Outage{{ComponentName: "removed"}}, expected: http.StatusForbidden},
+		{name: "admin", identity: &Identity{User: "admin", Groups: []string{"ship-admins"}}, outages: []types.Outage{{ComponentName: "Tide"}, {ComponentName: "build01"}}, expected: http.StatusOK},
+		{name: "anonymous", identity: nil, outages: []types.Outage{{ComponentName: "Tide"}}, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/incidents/1/outages", nil)
+			req = req.WithContext(withIdentity(req.Context(), tt.identity))
+			recorder := httptest.NewRecorder()
+
+			assert.Equal(t, tt.expected == http.StatusOK, handlers.authorizeOutages(recorder, req, tt.outages))
+			assert.Equal(t, tt.expected, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"ship-status-dash/pkg/types"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxLabels          = 32
+	maxLabelValueLen   = 255
+	maxLinks           = 32
+	maxLinkTitleLength = 255
+)
+
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+
+func validateLabels(labels map[string]string) (string, bool) {
+	if len(labels) > maxLabels {
+		return fmt.Sprintf("At most %d labels are allowed", maxLabels), false
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Sprintf("Invalid label key %q: must be 1-63 alphanumeric characters, '.', '_', '/' or '-', starting and ending with an alphanumeric character", key), false
+		}
+		if len(value) > maxLabelValueLen {
+			return fmt.Sprintf("Invalid value for label %q: must be at most %d characters", key, maxLabelValueLen), false
+		}
+	}
+	return "", true
+}
+
+func validateLinks(links []types.ExternalLink) (string, bool) {
+	if len(links) > maxLinks {
+		return fmt.Sprintf("At most %d links are allowed", maxLinks), false
+	}
+	for _, link := range links {
+		if !types.IsValidExternalLinkType(string(link.Type)) {
+			return "Invalid link type. Must be one of: jira, pull_request, slack, other", false
+		}
+		parsed, err := url.Parse(link.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Sprintf("Invalid link URL %q: must be an absolute http or https URL", link.URL), false
+		}
+		if len(link.Title) > maxLinkTitleLength {
+			return fmt.Sprintf("Invalid link title: must be at most %d characters", maxLinkTitleLength), false
+		}
+	}
+	return "", true
+}
+
+// jsonColumn encodes labels or links for a jsonb column when updating with a map, which bypasses the field's
+// serializer. Empty values are stored as NULL, as they are when the outage is created.
+func jsonColumn(value interface{}) interface{} {
+	encoded, _ := json.Marshal(value)
+	switch string(encoded) {
+	case "null", "{}", "[]":
+		return nil
+	}
+	return string(encoded)
+}
+
+// labelSelector matches outages that have the label, with the value if one is given.
+type labelSelector struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// outageQuery holds the filters accepted by the endpoints that list outages.
+type outageQuery struct {
+	Labels   []labelSelector
+	LinkType types.ExternalLinkType
+	Since    *time.Time
+	Until    *time.Time
+}
+
+// parseOutageQuery parses the outage filters. Each label parameter is either key=value, matching outages with
+// that label value, or key, matching outages that have the label at all.
+func parseOutageQuery(values url.Values) (outageQuery, string, bool) {
+	var query outageQuery
+
+	for _, raw := range values["label"] {
+		key, value, hasValue := strings.Cut(raw, "=")
+		if !labelKeyPattern.MatchString(key) {
+			return query, fmt.Sprintf("Invalid label selector %q: must be key or key=value", raw), false
+		}
+		query.Labels = append(query.Labels, labelSelector{Key: key, Value: value, HasValue: hasValue})
+	}
+
+	if raw := values.Get("link_type"); raw != "" {
+		if !types.IsValidExternalLinkType(raw) {
+			return query, "Invalid link_type. Must be one of: jira, pull_request, slack, other", false
+		}
+		query.LinkType = types.ExternalLinkType(raw)
+	}
+
+	for name, target := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		if raw := values.Get(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return query, "Invalid " + name + ": must be an RFC3339 timestamp", false
+			}
+			*target = &parsed
+		}
+	}
+
+	return query, "", true
+}
+
+func (q outageQuery) apply(db *gorm.DB) *gorm.DB {
+	for _, selector := range q.Labels {
+		if selector.HasValue {
+			db = db.Where("outages.labels ->> ?::text = ?", selector.Key, selector.Value)
+		} else {
+			db = db.Where("outages.labels ->> ?::text IS NOT NULL", selector.Key)
+		}
+	}
+	if q.LinkType != "" {
+		db = db.Where("outages.links @> ?", fmt.Sprintf(`[{"type":%q}]`, q.LinkType))
+	}
+	if q.Since != nil {
+		db = db.Where("outages.start_time >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		db = db.Where("outages.start_time < ?", *q.Until)
+	}
+	return db
+}
+
+// LabelCount is the number of outages carrying a label value.
+type LabelCount struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// GetLabelReportJSON counts outages by label value, most common first. The key query parameter restricts the
+// report to a single label, component restricts it to the outages of a component, and the outage filters accepted
+// by the list endpoints narrow down the outages that are counted.
+func (h *Handlers) GetLabelReportJSON(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	var component *types.Component
+	if componentName := values.Get("component"); componentName != "" {
+		component = h.getComponent(componentName)
+		if component == nil {
+			respondWithError(w, http.StatusNotFound, "Component not found")
+			return
+		}
+	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
+	query, message, valid := parseOutageQuery(values)
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	db := query.apply(h.db.Model(&types.Outage{})).
+		Select("label.key AS key, label.value AS value, COUNT(*) AS count").
+		Joins("CROSS JOIN LATERAL jsonb_each_text(outages.labels) AS label")
+	if key := values.Get("key"); key != "" {
+		db = db.Where("label.key = ?", key)
+	}
+	if component != nil {
+		subComponents := []string{}
+		for _, subComponent := range component.Subcomponents {
+			subComponents = append(subComponents, subComponent.Name)
+		}
+		db = db.Where("outages.component_name IN ?", subComponents)
+	}
+
+	counts := []LabelCount{}
+	if err := db.Group("label.key, label.value").Order("count DESC, label.key, label.value").Scan(&counts).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to count outage labels in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get label report")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, counts)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name            string
+		labels          map[string]string
+		expectedMessage string
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+		},
+		{
+			name:   "valid labels",
+			labels: map[string]string{"cause": "github", "ci.openshift.io/team": "dptp", "infra": ""},
+		},
+		{
+			name:            "invalid key",
+			labels:          map[string]string{"root cause": "github"},
+			expectedMessage: `Invalid label key "root cause": must be 1-63 alphanumeric characters, '.', '_', '/' or '-', starting and ending with an alphanumeric character`,
+		},
+		{
+			name:            "value too long",
+			labels:          map[string]string{"cause": strings.Repeat("x", 256)},
+			expectedMessage: `Invalid value for label "cause": must be at most 255 characters`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := validateLabels(tt.labels)
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
+
+func TestValidateLinks(t *testing.T) {
+	tests := []struct {
+		name            string
+		links           []types.ExternalLink
+		expectedMessage string
+	}{
+		{
+			name: "valid links",
+			links: []types.ExternalLink{
+				{Type: types.ExternalLinkJira, URL: "https://issues.example.com/browse/DPTP-1", Title: "DPTP-1"},
+				{Type: types.ExternalLinkPullRequest, URL: "https://github.com/openshift/release/pull/1"},
+			},
+		},
+		{
+			name:            "invalid type",
+			links:           []types.ExternalLink{{Type: "ticket", URL: "https://issues.example.com/browse/DPTP-1"}},
+			expectedMessage: "Invalid link type. Must be one of: jira, pull_request, slack, other",
+		},
+		{
+			name:            "relative URL",
+			links:           []types.ExternalLink{{Type: types.ExternalLinkSlack, URL: "/archives/C1/p1"}},
+			expectedMessage: `Invalid link URL "/archives/C1/p1": must be an absolute http or https URL`,
+		},
+		{
+			name:            "non-http URL",
+			links:           []types.ExternalLink{{Type: types.ExternalLinkOther, URL: "javascript:alert(1)"}},
+			expectedMessage: `Invalid link URL "javascript:alert(1)": must be an absolute http or https URL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := validateLinks(tt.links)
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
+
+func TestParseOutageQuery(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		values          url.Values
+		expected        outageQuery
+		expectedMessage string
+	}{
+		{
+			name:   "no filters",
+			values: url.Values{},
+		},
+		{
+			name: "all filters",
+			values: url.Values{
+				"label":     {"cause=github", "infra", "note=a=b"},
+				"link_type": {"jira"},
+				"since":     {"2025-01-01T00:00:00Z"},
+				"until":     {"2025-04-01T00:00:00Z"},
+			},
+			expected: outageQuery{
+				Labels: []labelSelector{
+					{Key: "cause", Value: "github", HasValue: true},
+					{Key: "infra"},
+					{Key: "note", Value: "a=b", HasValue: true},
+				},
+				LinkType: types.ExternalLinkJira,
+				Since:    &since,
+				Until:    &until,
+			},
+		},
+		{
+			name:            "invalid label selector",
+			values:          url.Values{"label": {"=github"}},
+			expectedMessage: `Invalid label selector "=github": must be key or key=value`,
+		},
+		{
+			name:            "invalid link type",
+			values:          url.Values{"link_type": {"ticket"}},
+			expectedMessage: "Invalid link_type. Must be one of: jira, pull_request, slack, other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, message, valid := parseOutageQuery(tt.values)
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+			if valid {
+				assert.Equal(t, tt.expected, query)
+			}
+		})
+	}
+}
+
+func TestJSONColumn(t *testing.T) {
+	assert.Nil(t, jsonColumn(map[string]string{}))
+	assert.Nil(t, jsonColumn([]types.ExternalLink(nil)))
+	assert.Equal(t, `{"cause":"github"}`, jsonColumn(map[string]string{"cause": "github"}))
+}
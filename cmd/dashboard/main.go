@@ -3,14 +3,16 @@ package main
 import (
 	"errors"
 	"flag"
-	"os"
 	"ship-status-dash/pkg/types"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v3"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Options contains command-line configuration options for the dashboard server.
@@ -19,16 +21,37 @@ type Options struct {
 	Port        string
 	DatabaseDSN string
 	CORSOrigin  string
+
+	TrustedProxies string
+	UserHeader     string
+	GroupsHeader   string
+	EmailHeader    string
+
+	TokenReview         bool
+	KubeconfigPath      string
+	TokenReviewAudience string
+	TokenReviewCacheTTL time.Duration
+
+	IdempotencyKeyTTL time.Duration
 }
 
 // NewOptions parses command-line flags and returns a new Options instance.
 func NewOptions() *Options {
 	opts := &Options{}
 
-	flag.StringVar(&opts.ConfigPath, "config", "", "Path to config file")
+	flag.StringVar(&opts.ConfigPath, "config", "", "Path to config file, directory of config files, or glob matching config files; with several files, default_role and role_bindings are only read from global.yaml")
 	flag.StringVar(&opts.Port, "port", "8080", "Port to listen on")
 	flag.StringVar(&opts.DatabaseDSN, "dsn", "", "PostgreSQL DSN connection string")
 	flag.StringVar(&opts.CORSOrigin, "cors-origin", "*", "Allowed CORS origin (use '*' for all origins)")
+	flag.StringVar(&opts.TrustedProxies, "trusted-proxies", "", "Comma-separated IP addresses or CIDR ranges of the authenticating proxy whose identity headers are trusted")
+	flag.StringVar(&opts.UserHeader, "auth-user-header", "X-Forwarded-User", "Header containing the authenticated user name set by the proxy")
+	flag.StringVar(&opts.GroupsHeader, "auth-groups-header", "X-Forwarded-Groups", "Header containing the comma-separated groups of the authenticated user set by the proxy")
+	flag.StringVar(&opts.EmailHeader, "auth-email-header", "X-Forwarded-Email", "Header containing the email of the authenticated user set by the proxy")
+	flag.BoolVar(&opts.TokenReview, "token-review", false, "Authenticate bearer tokens (such as service account tokens) using the Kubernetes TokenReview API")
+	flag.StringVar(&opts.KubeconfigPath, "kubeconfig", "", "Path to the kubeconfig used for TokenReview (uses in-cluster config when empty)")
+	flag.StringVar(&opts.TokenReviewAudience, "token-review-audiences", "", "Comma-separated audiences that reviewed tokens must be issued for (defaults to the API server audience)")
+	flag.DurationVar(&opts.TokenReviewCacheTTL, "token-review-cache-ttl", time.Minute, "How long successful token reviews are cached")
+	flag.DurationVar(&opts.IdempotencyKeyTTL, "idempotency-key-ttl", 24*time.Hour, "How long responses to requests with an Idempotency-Key header are kept for replay")
 	flag.Parse()
 
 	return opts
@@ -40,8 +63,8 @@ func (o *Options) Validate() error {
 		return errors.New("config path is required (use --config flag)")
 	}
 
-	if _, err := os.Stat(o.ConfigPath); os.IsNotExist(err) {
-		return errors.New("config file does not exist: " + o.ConfigPath)
+	if _, err := types.ConfigFiles(o.ConfigPath); err != nil {
+		return err
 	}
 
 	if o.Port == "" {
@@ -52,6 +75,18 @@ func (o *Options) Validate() error {
 		return errors.New("database DSN is required (use --dsn flag)")
 	}
 
+	if o.UserHeader == "" {
+		return errors.New("auth user header cannot be empty")
+	}
+
+	if o.TokenReviewCacheTTL < 0 {
+		return errors.New("token review cache TTL cannot be negative")
+	}
+
+	if o.IdempotencyKeyTTL <= 0 {
+		return errors.New("idempotency key TTL must be positive")
+	}
+
 	return nil
 }
 
@@ -67,24 +102,49 @@ func setupLogger() *logrus.Logger {
 func loadConfig(log *logrus.Logger, configPath string) *types.Config {
 	log.Infof("Loading config from %s", configPath)
 
-	configFile, err := os.ReadFile(configPath)
+	config, err := types.LoadConfig(configPath)
 	if err != nil {
 		log.WithFields(logrus.Fields{
 			"config_path": configPath,
 			"error":       err,
-		}).Fatal("Failed to read config file")
+		}).Fatal("Failed to load config")
 	}
 
-	var config types.Config
-	if err := yaml.Unmarshal(configFile, &config); err != nil {
-		log.WithFields(logrus.Fields{
-			"config_path": configPath,
-			"error":       err,
-		}).Fatal("Failed to parse config file")
+	log.Infof("Loaded configuration with %d components", len(config.Components))
+	return config
+}
+
+func setupAuthenticators(log *logrus.Logger, opts *Options, db *gorm.DB) []Authenticator {
+	proxyAuth, err := NewProxyAuthenticator(strings.Split(opts.TrustedProxies, ","), opts.UserHeader, opts.GroupsHeader, opts.EmailHeader)
+	if err != nil {
+		log.WithField("error", err).Fatal("Invalid trusted proxy configuration")
+	}
+	authenticators := []Authenticator{proxyAuth, NewAPITokenAuthenticator(db)}
+
+	if opts.TokenReview {
+		restConfig, err := clientcmd.BuildConfigFromFlags("", opts.KubeconfigPath)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"kubeconfig": opts.KubeconfigPath,
+				"error":      err,
+			}).Fatal("Failed to load Kubernetes client configuration")
+		}
+		client, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to create Kubernetes client")
+		}
+		var audiences []string
+		if opts.TokenReviewAudience != "" {
+			audiences = strings.Split(opts.TokenReviewAudience, ",")
+		}
+		authenticators = append(authenticators, NewTokenReviewAuthenticator(client, audiences, opts.TokenReviewCacheTTL))
+		log.Info("Bearer token authentication via TokenReview enabled")
 	}
 
-	log.Infof("Loaded configuration with %d components", len(config.Components))
-	return &config
+	if opts.TrustedProxies == "" && !opts.TokenReview {
+		log.Warn("No trusted proxies configured and TokenReview disabled, only personal API tokens can authenticate")
+	}
+	return authenticators
 }
 
 func connectDatabase(log *logrus.Logger, dsn string) *gorm.DB {
@@ -108,7 +168,8 @@ func main() {
 
 	config := loadConfig(log, opts.ConfigPath)
 	db := connectDatabase(log, opts.DatabaseDSN)
-	server := NewServer(config, db, log, opts.CORSOrigin)
+	authenticators := setupAuthenticators(log, opts, db)
+	server := NewServer(config, db, log, opts.CORSOrigin, authenticators, opts.IdempotencyKeyTTL)
 
 	addr := ":" + opts.Port
 	if err := server.Start(addr); err != nil {
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CreateOutageUpdateRequest represents the body of a request to post a status update on an outage.
+type CreateOutageUpdateRequest struct {
+	Message string `json:"message"`
+	Phase   string `json:"phase,omitempty"`
+}
+
+func (c *CreateOutageUpdateRequest) validate() (string, bool) {
+	if strings.TrimSpace(c.Message) == "" {
+		return "Message is required", false
+	}
+	if c.Phase != "" && !types.IsValidOutagePhase(c.Phase) {
+		return "Invalid phase. Must be one of: Investigating, Identified, Mitigating, Monitoring, Resolved", false
+	}
+	return "", true
+}
+
+// orderOutageUpdates lists outage updates oldest first, for use when preloading them.
+func orderOutageUpdates(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at, id")
+}
+
+// findOutage looks up the outage of the sub-component named in the request and responds with an error if it cannot
+// be found.
+func (h *Handlers) findOutage(w http.ResponseWriter, logger *logrus.Entry, subComponentName, outageId string) (*types.Outage, bool) {
+	var outage types.Outage
+	if err := h.db.Where("id = ? AND component_name = ?", outageId, subComponentName).First(&outage).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Outage not found")
+			return nil, false
+		}
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return nil, false
+	}
+	return &outage, true
+}
+
+// GetOutageUpdatesJSON lists the status updates posted on an outage, oldest first.
+func (h *Handlers) GetOutageUpdatesJSON(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	componentName := vars["componentName"]
+	subComponentName := vars["subComponentName"]
+	outageId := vars["outageId"]
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"component":     componentName,
+		"sub_component": subComponentName,
+		"outage_id":     outageId,
+	})
+
+	component := h.getComponent(componentName)
+	if component == nil {
+		respondWithError(w, http.StatusNotFound, "Component not found")
+		return
+	}
+	if component.GetSubComponent(subComponentName) == nil {
+		respondWithError(w, http.StatusNotFound, "Sub-component not found")
+		return
+	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
+	outage, found := h.findOutage(w, logger, subComponentName, outageId)
+	if !found {
+		return
+	}
+
+	var updates []types.OutageUpdate
+	if err := orderOutageUpdates(h.db).Where("outage_id = ?", outage.ID).Find(&updates).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to query outage updates from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage updates")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, updates)
+}
+
+// CreateOutageUpdateJSON posts a status update on an outage. The author is taken from the authenticated identity.
+func (h *Handlers) CreateOutageUpdateJSON(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	componentName := vars["componentName"]
+	subComponentName := vars["subComponentName"]
+	outageId := vars["outageId"]
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"component":     componentName,
+		"sub_component": subComponentName,
+		"outage_id":     outageId,
+	})
+
+	component := h.getComponent(componentName)
+	if component == nil {
+		respondWithError(w, http.StatusNotFound, "Component not found")
+		return
+	}
+	if component.GetSubComponent(subComponentName) == nil {
+		respondWithError(w, http.StatusNotFound, "Sub-component not found")
+		return
+	}
+
+	if !h.authorize(w, r, component, ActionPostOutageUpdate) {
+		return
+	}
+
+	var req CreateOutageUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	outage, found := h.findOutage(w, logger, subComponentName, outageId)
+	if !found {
+		return
+	}
+
+	update := types.OutageUpdate{
+		OutageID: outage.ID,
+		Message:  req.Message,
+		Author:   identityFromContext(r.Context()).User,
+		Phase:    types.OutagePhase(req.Phase),
+	}
+	logger = logger.WithFields(logrus.Fields{
+		"author": update.Author,
+		"phase":  update.Phase,
+	})
+	if err := h.db.Create(&update).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to create outage update in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create outage update")
+		return
+	}
+
+	logger.Infof("Successfully posted outage update: %d", update.ID)
+	respondWithJSON(w, http.StatusCreated, update)
+}
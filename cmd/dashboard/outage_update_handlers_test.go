@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOutageUpdateRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         CreateOutageUpdateRequest
+		expectedMessage string
+	}{
+		{
+			name:    "message only",
+			request: CreateOutageUpdateRequest{Message: "Looking into failed merges"},
+		},
+		{
+			name:    "message with phase",
+			request: CreateOutageUpdateRequest{Message: "Rolled back the config change", Phase: "Mitigating"},
+		},
+		{
+			name:            "missing message",
+			request:         CreateOutageUpdateRequest{Message: " ", Phase: "Identified"},
+			expectedMessage: "Message is required",
+		},
+		{
+			name:            "invalid phase",
+			request:         CreateOutageUpdateRequest{Message: "Fixed", Phase: "identified"},
+			expectedMessage: "Invalid phase. Must be one of: Investigating, Identified, Mitigating, Monitoring, Resolved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := tt.request.validate()
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
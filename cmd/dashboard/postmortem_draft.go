@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"ship-status-dash/pkg/types"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const postmortemTimeFormat = "2006-01-02 15:04 MST"
+
+// timelineEntry is a line of the timeline of a generated postmortem draft.
+type timelineEntry struct {
+	At      time.Time
+	Message string
+}
+
+// outageTimeline reconstructs the history of an outage from its fields, reports and status updates, oldest first.
+func outageTimeline(outage *types.Outage) []timelineEntry {
+	started := fmt.Sprintf("Outage started with severity %s, reported by %s", outage.Severity, outage.CreatedBy)
+	if outage.DiscoveredFrom != "" {
+		started += fmt.Sprintf(" (discovered from %s)", outage.DiscoveredFrom)
+	}
+	entries := []timelineEntry{{At: outage.StartTime, Message: started}}
+
+	for _, report := range outage.Reports {
+		message := fmt.Sprintf("Reported again by %s with severity %s", report.ReportedBy, report.Severity)
+		if report.Description != "" {
+			message += ": " + singleLine(report.Description)
+		}
+		entries = append(entries, timelineEntry{At: report.CreatedAt, Message: message})
+	}
+	if outage.ConfirmedAt.Valid {
+		message := "Outage confirmed"
+		if outage.ConfirmedBy != nil {
+			message += " by " + *outage.ConfirmedBy
+		}
+		entries = append(entries, timelineEntry{At: outage.ConfirmedAt.Time, Message: message})
+	}
+	for _, update := range outage.Updates {
+		message := "Update from " + update.Author
+		if update.Phase != "" {
+			message += fmt.Sprintf(" (%s)", update.Phase)
+		}
+		entries = append(entries, timelineEntry{At: update.CreatedAt, Message: message + ": " + singleLine(update.Message)})
+	}
+	if outage.EndTime.Valid {
+		message := "Outage resolved"
+		if outage.ResolvedBy != nil {
+			message += " by " + *outage.ResolvedBy
+		}
+		entries = append(entries, timelineEntry{At: outage.EndTime.Time, Message: message})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	return entries
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func formatOutageDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// orPlaceholder returns text, or a placeholder asking for it to be written when it is empty.
+func orPlaceholder(text, placeholder string) string {
+	if strings.TrimSpace(text) == "" {
+		return "_TODO: " + placeholder + "_"
+	}
+	return strings.TrimSpace(text)
+}
+
+// postmortemDraft generates a Markdown postmortem for an outage of a sub-component of the named component,
+// pre-filled from the outage, its reports and its status updates. Sections that cannot be derived are left as TODOs.
+func postmortemDraft(componentName string, outage *types.Outage) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Postmortem: %s/%s outage %d\n\n", componentName, outage.ComponentName, outage.ID)
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString(orPlaceholder(outage.Description, "summarize what happened") + "\n\n")
+
+	b.WriteString("## Impact\n\n")
+	fmt.Fprintf(&b, "- **Component:** %s / %s\n", componentName, outage.ComponentName)
+	fmt.Fprintf(&b, "- **Severity:** %s\n", outage.Severity)
+	fmt.Fprintf(&b, "- **Started:** %s\n", outage.StartTime.UTC().Format(postmortemTimeFormat))
+	if outage.EndTime.Valid {
+		fmt.Fprintf(&b, "- **Resolved:** %s (duration %s)\n", outage.EndTime.Time.UTC().Format(postmortemTimeFormat),
+			formatOutageDuration(outage.EndTime.Time.Sub(outage.StartTime)))
+	} else {
+		b.WriteString("- **Resolved:** ongoing\n")
+	}
+	if len(outage.Labels) > 0 {
+		var labels []string
+		for _, key := range slices.Sorted(maps.Keys(outage.Labels)) {
+			if value := outage.Labels[key]; value != "" {
+				labels = append(labels, fmt.Sprintf("`%s=%s`", key, value))
+			} else {
+				labels = append(labels, fmt.Sprintf("`%s`", key))
+			}
+		}
+		fmt.Fprintf(&b, "- **Labels:** %s\n", strings.Join(labels, ", "))
+	}
+	b.WriteString("\n" + orPlaceholder("", "describe who and what was affected") + "\n\n")
+
+	b.WriteString("## Root cause\n\n")
+	rootCause := ""
+	if outage.TriageNotes != nil {
+		rootCause = *outage.TriageNotes
+	}
+	b.WriteString(orPlaceholder(rootCause, "describe the root cause") + "\n\n")
+
+	b.WriteString("## Timeline\n\n")
+	for _, entry := range outageTimeline(outage) {
+		fmt.Fprintf(&b, "- **%s** %s\n", entry.At.UTC().Format(postmortemTimeFormat), entry.Message)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Action items\n\n")
+	b.WriteString("- [ ] _TODO: action item_ (owner: _TODO_, due: _TODO_)\n")
+
+	if len(outage.Links) > 0 {
+		b.WriteString("\n## References\n\n")
+		for _, link := range outage.Links {
+			title := link.Title
+			if title == "" {
+				title = link.URL
+			}
+			fmt.Fprintf(&b, "- %s: [%s](%s)\n", link.Type, title, link.URL)
+		}
+	}
+
+	return b.String()
+}
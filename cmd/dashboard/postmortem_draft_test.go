@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestOutageTimeline(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	strPtr := func(s string) *string { return &s }
+
+	outage := &types.Outage{
+		Severity:       types.SeverityDown,
+		StartTime:      start,
+		EndTime:        sql.NullTime{Time: start.Add(2 * time.Hour), Valid: true},
+		CreatedBy:      "alice",
+		DiscoveredFrom: "slack",
+		ResolvedBy:     strPtr("bob"),
+		ConfirmedAt:    sql.NullTime{Time: start.Add(10 * time.Minute), Valid: true},
+		ConfirmedBy:    strPtr("carol"),
+		Reports: []types.OutageReport{
+			{Model: gorm.Model{CreatedAt: start.Add(5 * time.Minute)}, ReportedBy: "dave", Severity: types.SeverityDegraded, Description: "Merges\nare slow"},
+		},
+		Updates: []types.OutageUpdate{
+			{Model: gorm.Model{CreatedAt: start.Add(time.Hour)}, Author: "bob", Phase: types.OutagePhaseMitigating, Message: "Rolled back"},
+		},
+	}
+
+	assert.Equal(t, []timelineEntry{
+		{At: start, Message: "Outage started with severity Down, reported by alice (discovered from slack)"},
+		{At: start.Add(5 * time.Minute), Message: "Reported again by dave with severity Degraded: Merges are slow"},
+		{At: start.Add(10 * time.Minute), Message: "Outage confirmed by carol"},
+		{At: start.Add(time.Hour), Message: "Update from bob (Mitigating): Rolled back"},
+		{At: start.Add(2 * time.Hour), Message: "Outage resolved by bob"},
+	}, outageTimeline(outage))
+}
+
+func TestPostmortemDraft(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	notes := "A GitHub outage stopped webhooks."
+
+	tests := []struct {
+		name     string
+		outage   types.Outage
+		expected string
+	}{
+		{
+			name: "resolved outage with notes, labels and links",
+			outage: types.Outage{
+				Model:       gorm.Model{ID: 42},
+				Description: "Tide stopped merging",
+				Severity:    types.SeverityDown, StartTime: start, CreatedBy: "alice", ComponentName: "Tide",
+				EndTime:     sql.NullTime{Time: start.Add(90 * time.Minute), Valid: true},
+				TriageNotes: &notes,
+				Labels:      map[string]string{"infra": "", "cause": "github"},
+				Links:       []types.ExternalLink{{Type: types.ExternalLinkJira, URL: "https://issues.example.com/browse/DPTP-1", Title: "DPTP-1"}},
+			},
+			expected: `# Postmortem: Prow/Tide outage 42
+
+## Summary
+
+Tide stopped merging
+
+## Impact
+
+- **Component:** Prow / Tide
+- **Severity:** Down
+- **Started:** 2025-01-01 12:00 UTC
+- **Resolved:** 2025-01-01 13:30 UTC (duration 1h 30m)
+- **Labels:** ` + "`cause=github`, `infra`" + `
+
+_TODO: describe who and what was affected_
+
+## Root cause
+
+A GitHub outage stopped webhooks.
+
+## Timeline
+
+- **2025-01-01 12:00 UTC** Outage started with severity Down, reported by alice
+- **2025-01-01 13:30 UTC** Outage resolved
+
+## Action items
+
+- [ ] _TODO: action item_ (owner: _TODO_, due: _TODO_)
+
+## References
+
+- jira: [DPTP-1](https://issues.example.com/browse/DPTP-1)
+`,
+		},
+		{
+			name:   "ongoing outage without details",
+			outage: types.Outage{Model: gorm.Model{ID: 7}, Severity: types.SeverityDown, StartTime: start, CreatedBy: "alice", ComponentName: "Tide"},
+			expected: `# Postmortem: Prow/Tide outage 7
+
+## Summary
+
+_TODO: summarize what happened_
+
+## Impact
+
+- **Component:** Prow / Tide
+- **Severity:** Down
+- **Started:** 2025-01-01 12:00 UTC
+- **Resolved:** ongoing
+
+_TODO: describe who and what was affected_
+
+## Root cause
+
+_TODO: describe the root cause_
+
+## Timeline
+
+- **2025-01-01 12:00 UTC** Outage started with severity Down, reported by alice
+
+## Action items
+
+- [ ] _TODO: action item_ (owner: _TODO_, due: _TODO_)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, postmortemDraft("Prow", &tt.outage))
+		})
+	}
+}
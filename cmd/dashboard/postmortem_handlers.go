@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"ship-status-dash/pkg/types"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// postmortemRequiredSeverity is the severity of outages that need a postmortem once they are resolved.
+const postmortemRequiredSeverity = types.SeverityDown
+
+// PostmortemRequest represents the body of a request to create or replace a postmortem. The outage or incident
+// that a postmortem belongs to is set when it is created and cannot be changed afterwards.
+type PostmortemRequest struct {
+	OutageID    *uint                        `json:"outage_id,omitempty"`
+	IncidentID  *uint                        `json:"incident_id,omitempty"`
+	Summary     string                       `json:"summary"`
+	Impact      string                       `json:"impact"`
+	RootCause   string                       `json:"root_cause"`
+	Timeline    string                       `json:"timeline"`
+	ActionItems []types.PostmortemActionItem `json:"action_items"`
+}
+
+func (p *PostmortemRequest) validateTarget() (string, bool) {
+	if (p.OutageID == nil) == (p.IncidentID == nil) {
+		return "Exactly one of outage_id and incident_id is required", false
+	}
+	return "", true
+}
+
+func (p *PostmortemRequest) validate() (string, bool) {
+	for i, item := range p.ActionItems {
+		if strings.TrimSpace(item.Description) == "" {
+			return fmt.Sprintf("Action item %d: description is required", i+1), false
+		}
+		if strings.TrimSpace(item.Owner) == "" {
+			return fmt.Sprintf("Action item %d: owner is required", i+1), false
+		}
+	}
+	return "", true
+}
+
+// authorizePostmortem checks that the caller may write the postmortem of the outage or incident, responding with an
+// error if the target does not exist or the caller is not allowed to.
+func (h *Handlers) authorizePostmortem(w http.ResponseWriter, r *http.Request, logger *logrus.Entry, outageID, incidentID *uint) bool {
+	if incidentID != nil {
+		if _, found := h.findIncident(w, logger, fmt.Sprint(*incidentID)); !found {
+			return false
+		}
+		return h.authorizeAnyComponent(w, r, ActionWritePostmortem)
+	}
+
+	var outage types.Outage
+	if err := h.db.First(&outage, *outageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Outage not found")
+			return false
+		}
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return false
+	}
+	return h.authorize(w, r, h.componentForSubComponent(outage.ComponentName), ActionWritePostmortem)
+}
+
+// findPostmortem looks up the postmortem named in the request and responds with an error if it cannot be found.
+func (h *Handlers) findPostmortem(w http.ResponseWriter, logger *logrus.Entry, postmortemID string) (*types.Postmortem, bool) {
+	var postmortem types.Postmortem
+	if err := h.db.First(&postmortem, "id = ?", postmortemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Postmortem not found")
+			return nil, false
+		}
+		logger.WithField("error", err).Error("Failed to query postmortem from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get postmortem")
+		return nil, false
+	}
+	return &postmortem, true
+}
+
+// GetPostmortemsJSON lists postmortems, most recent first. The outage_id and incident_id query parameters find the
+// postmortem of an outage or incident.
+func (h *Handlers) GetPostmortemsJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
+
+	query := h.db
+	if outageID := r.URL.Query().Get("outage_id"); outageID != "" {
+		query = query.Where("outage_id = ?", outageID)
+	}
+	if incidentID := r.URL.Query().Get("incident_id"); incidentID != "" {
+		query = query.Where("incident_id = ?", incidentID)
+	}
+
+	var postmortems []types.Postmortem
+	if err := query.Order("created_at DESC").Find(&postmortems).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to query postmortems from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get postmortems")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, postmortems)
+}
+
+// GetPostmortemJSON retrieves a single postmortem.
+func (h *Handlers) GetPostmortemJSON(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, nil, ActionViewStatus) {
+		return
+	}
+
+	postmortemID := mux.Vars(r)["postmortemId"]
+	postmortem, found := h.findPostmortem(w, h.logger.WithField("postmortem_id", postmortemID), postmortemID)
+	if !found {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, postmortem)
+}
+
+// CreatePostmortemJSON creates the postmortem of an outage or incident. Each outage and incident has at most one.
+func (h *Handlers) CreatePostmortemJSON(w http.ResponseWriter, r *http.Request) {
+	var req PostmortemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, valid := req.validateTarget(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"outage_id":   req.OutageID,
+		"incident_id": req.IncidentID,
+		"user":        identity.User,
+	})
+
+	if !h.authorizePostmortem(w, r, logger, req.OutageID, req.IncidentID) {
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	var existing []types.Postmortem
+	if err := h.db.Where("outage_id = ? OR incident_id = ?", req.OutageID, req.IncidentID).Limit(1).Find(&existing).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to query postmortems from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create postmortem")
+		return
+	}
+	if len(existing) > 0 {
+		w.Header().Set("Location", fmt.Sprintf("/api/postmortems/%d", existing[0].ID))
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("A postmortem already exists: %d", existing[0].ID))
+		return
+	}
+
+	postmortem := types.Postmortem{
+		OutageID:    req.OutageID,
+		IncidentID:  req.IncidentID,
+		Summary:     req.Summary,
+		Impact:      req.Impact,
+		RootCause:   req.RootCause,
+		Timeline:    req.Timeline,
+		ActionItems: req.ActionItems,
+		CreatedBy:   identity.User,
+		UpdatedBy:   identity.User,
+	}
+	if err := h.db.Create(&postmortem).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to create postmortem in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to create postmortem")
+		return
+	}
+
+	logger.Infof("Successfully created postmortem: %d", postmortem.ID)
+	respondWithJSON(w, http.StatusCreated, postmortem)
+}
+
+// UpdatePostmortemJSON replaces the content of a postmortem.
+func (h *Handlers) UpdatePostmortemJSON(w http.ResponseWriter, r *http.Request) {
+	postmortemID := mux.Vars(r)["postmortemId"]
+	identity := identityFromContext(r.Context())
+	logger := h.logger.WithFields(logrus.Fields{
+		"postmortem_id": postmortemID,
+		"user":          identity.User,
+	})
+
+	var req PostmortemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	postmortem, found := h.findPostmortem(w, logger, postmortemID)
+	if !found {
+		return
+	}
+	if !h.authorizePostmortem(w, r, logger, postmortem.OutageID, postmortem.IncidentID) {
+		return
+	}
+	if message, valid := req.validate(); !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	postmortem.Summary = req.Summary
+	postmortem.Impact = req.Impact
+	postmortem.RootCause = req.RootCause
+	postmortem.Timeline = req.Timeline
+	postmortem.ActionItems = req.ActionItems
+	postmortem.UpdatedBy = identity.User
+	if err := h.db.Save(postmortem).Error; err != nil {
+		logger.WithField("error", err).Error("Failed to update postmortem in database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to update postmortem")
+		return
+	}
+
+	logger.Info("Successfully updated postmortem")
+	respondWithJSON(w, http.StatusOK, postmortem)
+}
+
+// GetPostmortemDraft generates a Markdown postmortem draft for an outage, pre-filled from the outage, its reports
+// and its status updates.
+func (h *Handlers) GetPostmortemDraft(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	componentName := vars["componentName"]
+	subComponentName := vars["subComponentName"]
+	outageId := vars["outageId"]
+
+	logger := h.logger.WithFields(logrus.Fields{
+		"component":     componentName,
+		"sub_component": subComponentName,
+		"outage_id":     outageId,
+	})
+
+	component := h.getComponent(componentName)
+	if component == nil {
+		respondWithError(w, http.StatusNotFound, "Component not found")
+		return
+	}
+	if component.GetSubComponent(subComponentName) == nil {
+		respondWithError(w, http.StatusNotFound, "Sub-component not found")
+		return
+	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
+	var outage types.Outage
+	if err := h.db.Preload("Reports").Preload("Updates", orderOutageUpdates).Where("id = ? AND component_name = ?", outageId, subComponentName).First(&outage).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "Outage not found")
+			return
+		}
+		logger.WithField("error", err).Error("Failed to query outage from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outage")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(postmortemDraft(componentName, &outage)))
+}
+
+// GetMissingPostmortemsJSON lists resolved outages that require a postmortem but have none, either of their own or
+// of the incident they belong to. It accepts the component query parameter and the outage filters of the list
+// endpoints.
+func (h *Handlers) GetMissingPostmortemsJSON(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	var component *types.Component
+	if componentName := values.Get("component"); componentName != "" {
+		component = h.getComponent(componentName)
+		if component == nil {
+			respondWithError(w, http.StatusNotFound, "Component not found")
+			return
+		}
+	}
+
+	if !h.authorize(w, r, component, ActionViewStatus) {
+		return
+	}
+
+	query, message, valid := parseOutageQuery(values)
+	if !valid {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	db := query.apply(h.db).
+		Where("outages.severity = ? AND outages.end_time IS NOT NULL AND outages.end_time <= ?", postmortemRequiredSeverity, time.Now()).
+		Where(`NOT EXISTS (SELECT 1 FROM postmortems WHERE postmortems.deleted_at IS NULL AND
+			(postmortems.outage_id = outages.id OR postmortems.incident_id = outages.incident_id))`)
+	if component != nil {
+		subComponents := []string{}
+		for _, subComponent := range component.Subcomponents {
+			subComponents = append(subComponents, subComponent.Name)
+		}
+		db = db.Where("outages.component_name IN ?", subComponents)
+	}
+
+	outages := []types.Outage{}
+	if err := db.Order("outages.start_time DESC").Find(&outages).Error; err != nil {
+		h.logger.WithField("error", err).Error("Failed to query outages missing postmortems from database")
+		respondWithError(w, http.StatusInternalServerError, "Failed to get outages missing postmortems")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, outages)
+}
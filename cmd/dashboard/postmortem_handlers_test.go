@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostmortemRequest_Validate(t *testing.T) {
+	id := uint(1)
+
+	tests := []struct {
+		name            string
+		request         PostmortemRequest
+		expectedMessage string
+	}{
+		{
+			name:    "outage postmortem",
+			request: PostmortemRequest{OutageID: &id, ActionItems: []types.PostmortemActionItem{{Description: "Add alerting", Owner: "alice"}}},
+		},
+		{
+			name:    "incident postmortem",
+			request: PostmortemRequest{IncidentID: &id},
+		},
+		{
+			name:            "no target",
+			request:         PostmortemRequest{},
+			expectedMessage: "Exactly one of outage_id and incident_id is required",
+		},
+		{
+			name:            "both targets",
+			request:         PostmortemRequest{OutageID: &id, IncidentID: &id},
+			expectedMessage: "Exactly one of outage_id and incident_id is required",
+		},
+		{
+			name:            "action item without owner",
+			request:         PostmortemRequest{OutageID: &id, ActionItems: []types.PostmortemActionItem{{Description: "Add alerting"}}},
+			expectedMessage: "Action item 1: owner is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, valid := tt.request.validateTarget()
+			if valid {
+				message, valid = tt.request.validate()
+			}
+			assert.Equal(t, tt.expectedMessage, message)
+			assert.Equal(t, tt.expectedMessage == "", valid)
+		})
+	}
+}
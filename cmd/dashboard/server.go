@@ -1,6 +1,9 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
 	"ship-status-dash/pkg/types"
 	"time"
@@ -11,6 +14,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// maxRequestBodySize bounds the request bodies that middlewares buffer before the handlers see them.
+const maxRequestBodySize = 1 << 20
+
+// readRequestBody buffers the body of the request and replaces it with the buffer, so that the handler can still
+// read it. It responds with 413 or 400 and returns false when the body is too large or cannot be read.
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+		} else {
+			respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		}
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
 // Server represents the HTTP server for the dashboard API.
 type Server struct {
 	logger     *logrus.Logger
@@ -18,23 +41,32 @@ type Server struct {
 	handlers   *Handlers
 	db         *gorm.DB
 	corsOrigin string
+	// authenticators are consulted in order, and the first to identify the caller wins
+	authenticators []Authenticator
+	auditLog       auditAppender
+	// idempotencyKeyTTL is how long responses to requests with an Idempotency-Key header are kept for replay
+	idempotencyKeyTTL time.Duration
 }
 
-// NewServer creates a new Server instance with the provided configuration, database connection, and logger.
-func NewServer(config *types.Config, db *gorm.DB, logger *logrus.Logger, corsOrigin string) *Server {
+// NewServer creates a new Server instance with the provided configuration, database connection, logger, and authenticators.
+func NewServer(config *types.Config, db *gorm.DB, logger *logrus.Logger, corsOrigin string, authenticators []Authenticator, idempotencyKeyTTL time.Duration) *Server {
 	handlers := NewHandlers(logger, config, db)
 
 	return &Server{
-		logger:     logger,
-		config:     config,
-		handlers:   handlers,
-		db:         db,
-		corsOrigin: corsOrigin,
+		logger:            logger,
+		config:            config,
+		handlers:          handlers,
+		db:                db,
+		corsOrigin:        corsOrigin,
+		authenticators:    authenticators,
+		auditLog:          NewAuditLog(db),
+		idempotencyKeyTTL: idempotencyKeyTTL,
 	}
 }
 
 func (s *Server) setupRoutes() http.Handler {
 	router := mux.NewRouter()
+	router.Use(s.idempotencyMiddleware)
 
 	router.HandleFunc("/health", s.handlers.HealthJSON).Methods("GET")
 
@@ -45,21 +77,54 @@ func (s *Server) setupRoutes() http.Handler {
 	router.HandleFunc("/api/components", s.handlers.GetComponentsJSON).Methods("GET")
 	router.HandleFunc("/api/components/{componentName}", s.handlers.GetComponentInfoJSON).Methods("GET")
 	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", s.handlers.GetOutageJSON).Methods("GET")
-	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", s.handlers.UpdateOutageJSON).Methods("PATCH")
-	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", s.handlers.DeleteOutage).Methods("DELETE")
-	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages", s.handlers.CreateOutageJSON).Methods("POST")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", s.requireAuthentication(s.handlers.UpdateOutageJSON)).Methods("PATCH")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", s.requireAuthentication(s.handlers.DeleteOutage)).Methods("DELETE")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}/updates", s.handlers.GetOutageUpdatesJSON).Methods("GET")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}/updates", s.requireAuthentication(s.handlers.CreateOutageUpdateJSON)).Methods("POST")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}/postmortem/draft", s.handlers.GetPostmortemDraft).Methods("GET")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages", s.requireAuthentication(s.handlers.CreateOutageJSON)).Methods("POST")
+	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages/merge", s.requireAuthentication(s.handlers.MergeOutagesJSON)).Methods("POST")
 	router.HandleFunc("/api/components/{componentName}/{subComponentName}/outages", s.handlers.GetSubComponentOutagesJSON).Methods("GET")
 	router.HandleFunc("/api/components/{componentName}/outages", s.handlers.GetOutagesJSON).Methods("GET")
 
+	router.HandleFunc("/api/reports/labels", s.handlers.GetLabelReportJSON).Methods("GET")
+	router.HandleFunc("/api/reports/missing-postmortems", s.handlers.GetMissingPostmortemsJSON).Methods("GET")
+
+	router.HandleFunc("/api/postmortems", s.handlers.GetPostmortemsJSON).Methods("GET")
+	router.HandleFunc("/api/postmortems", s.requireAuthentication(s.handlers.CreatePostmortemJSON)).Methods("POST")
+	router.HandleFunc("/api/postmortems/{postmortemId:[0-9]+}", s.handlers.GetPostmortemJSON).Methods("GET")
+	router.HandleFunc("/api/postmortems/{postmortemId:[0-9]+}", s.requireAuthentication(s.handlers.UpdatePostmortemJSON)).Methods("PUT")
+
+	router.HandleFunc("/api/incidents", s.handlers.GetIncidentsJSON).Methods("GET")
+	router.HandleFunc("/api/incidents", s.requireAuthentication(s.handlers.CreateIncidentJSON)).Methods("POST")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}", s.handlers.GetIncidentJSON).Methods("GET")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}", s.requireAuthentication(s.handlers.UpdateIncidentJSON)).Methods("PATCH")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}", s.requireAuthentication(s.handlers.DeleteIncident)).Methods("DELETE")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}/timeline", s.requireAuthentication(s.handlers.AddIncidentNoteJSON)).Methods("POST")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}/outages", s.requireAuthentication(s.handlers.LinkOutageJSON)).Methods("POST")
+	router.HandleFunc("/api/incidents/{incidentId:[0-9]+}/outages/{outageId:[0-9]+}", s.requireAuthentication(s.handlers.UnlinkOutage)).Methods("DELETE")
+
+	router.HandleFunc("/api/tokens", s.requireAuthentication(s.handlers.GetAPITokensJSON)).Methods("GET")
+	router.HandleFunc("/api/tokens", s.requireAuthentication(s.handlers.CreateAPITokenJSON)).Methods("POST")
+	router.HandleFunc("/api/tokens/{tokenId:[0-9]+}", s.requireAuthentication(s.handlers.RevokeAPIToken)).Methods("DELETE")
+
+	router.HandleFunc("/api/admin/audit", s.requireAuthentication(s.handlers.GetAuditLogJSON)).Methods("GET")
+	router.HandleFunc("/api/admin/audit/verify", s.requireAuthentication(s.handlers.VerifyAuditLogJSON)).Methods("GET")
+	router.HandleFunc("/api/admin/outages/deleted", s.requireAuthentication(s.handlers.GetDeletedOutagesJSON)).Methods("GET")
+	router.HandleFunc("/api/admin/outages/deleted/{outageId:[0-9]+}", s.requireAuthentication(s.handlers.GetDeletedOutageJSON)).Methods("GET")
+	router.HandleFunc("/api/admin/outages/deleted/{outageId:[0-9]+}/restore", s.requireAuthentication(s.handlers.RestoreOutageJSON)).Methods("POST")
+
 	corsHandler := handlers.CORS(
 		handlers.AllowedOrigins([]string{s.corsOrigin}),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "If-Match", "Idempotency-Key"}),
+		handlers.ExposedHeaders([]string{"ETag", idempotentReplayedHeader}),
 		handlers.AllowCredentials(),
 	)(router)
 
-	// Add logging middleware
-	handler := s.loggingMiddleware(corsHandler)
+	handler := s.authMiddleware(corsHandler)
+	handler = s.auditMiddleware(router, handler)
+	handler = s.loggingMiddleware(handler)
 
 	return handler
 }
@@ -78,9 +143,98 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// auditMiddleware records every mutating request in the audit log, including those rejected during authentication.
+// The router is only used to resolve the route template of the request.
+func (s *Server) auditMiddleware(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutatingMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := &types.AuditLogEntry{
+			Timestamp:    time.Now().UTC().Truncate(time.Microsecond),
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			SourceIP:     sourceIP(r),
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		}
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			entry.Route, _ = match.Route.GetPathTemplate()
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		state := &auditState{}
+		body, ok := readRequestBody(recorder, r)
+		entry.BodyHash = hashBody(body)
+		if ok {
+			next.ServeHTTP(recorder, r.WithContext(withAuditState(r.Context(), state)))
+		}
+
+		entry.StatusCode = recorder.status
+		if entry.StatusCode == 0 {
+			entry.StatusCode = http.StatusOK
+		}
+		if state.identity != nil {
+			entry.User = state.identity.User
+			entry.AuthMethod = string(state.identity.Method)
+		}
+
+		if err := s.auditLog.Append(entry); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"method": entry.Method,
+				"path":   entry.Path,
+				"user":   entry.User,
+				"status": entry.StatusCode,
+				"error":  err,
+			}).Error("Failed to write audit log entry")
+		}
+	})
+}
+
+// authMiddleware attaches the identity of the caller to the request context.
+// Requests without credentials continue anonymously so that read endpoints stay public,
+// while requests with invalid credentials are rejected outright.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, authenticator := range s.authenticators {
+			identity, err := authenticator.Authenticate(r)
+			if err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"remote_addr": r.RemoteAddr,
+					"path":        r.URL.Path,
+					"error":       err,
+				}).Warn("Authentication failed")
+				respondWithError(w, http.StatusUnauthorized, "Invalid credentials")
+				return
+			}
+			if identity != nil {
+				recordAuditIdentity(r.Context(), identity)
+				next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), identity)))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAuthentication rejects anonymous requests to the wrapped handler.
+func (s *Server) requireAuthentication(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if identityFromContext(r.Context()) == nil {
+			respondWithError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		next(w, r)
+	}
+}
+
 // Start begins listening for HTTP requests on the specified address.
 func (s *Server) Start(addr string) error {
 	handler := s.setupRoutes()
+	go s.cleanupExpiredIdempotencyKeys()
 	s.logger.Infof("Starting dashboard server on %s", addr)
 	return http.ListenAndServe(addr, handler)
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRequestBody(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "small body", body: `{"severity":"Down"}`},
+		{name: "body at the limit", body: strings.Repeat("a", maxRequestBodySize)},
+		{name: "body over the limit", body: strings.Repeat("a", maxRequestBodySize+1), expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/components/Prow/Tide/outages", strings.NewReader(tt.body))
+			recorder := httptest.NewRecorder()
+
+			body, ok := readRequestBody(recorder, req)
+			if tt.expectedStatus != 0 {
+				assert.False(t, ok)
+				assert.Equal(t, tt.expectedStatus, recorder.Code)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.body, string(body))
+
+			reread, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(reread))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type cachedReview struct {
+	identity *Identity
+	expires  time.Time
+}
+
+// TokenReviewAuthenticator validates bearer tokens, such as in-cluster service account tokens,
+// using the Kubernetes TokenReview API. Successful reviews are cached for a short time so that
+// frequent callers do not cause a TokenReview for every request.
+type TokenReviewAuthenticator struct {
+	client    kubernetes.Interface
+	audiences []string
+	cacheTTL  time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedReview
+}
+
+// NewTokenReviewAuthenticator creates a TokenReviewAuthenticator using the provided clientset.
+func NewTokenReviewAuthenticator(client kubernetes.Interface, audiences []string, cacheTTL time.Duration) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{
+		client:    client,
+		audiences: audiences,
+		cacheTTL:  cacheTTL,
+		now:       time.Now,
+		cache:     make(map[[sha256.Size]byte]cachedReview),
+	}
+}
+
+// bearerToken returns the token from the Authorization header, or an empty string if there is none.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[len("Bearer "):])
+}
+
+// Authenticate reviews the request's bearer token. Requests without a bearer token are left to other authenticators.
+func (a *TokenReviewAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+
+	key := sha256.Sum256([]byte(token))
+	if identity := a.cachedIdentity(key); identity != nil {
+		return identity, nil
+	}
+
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, fmt.Errorf("token is not valid: %s", review.Status.Error)
+		}
+		return nil, errors.New("token is not valid")
+	}
+
+	identity := &Identity{
+		User:   review.Status.User.Username,
+		Groups: review.Status.User.Groups,
+		Method: AuthMethodTokenReview,
+	}
+	a.cacheIdentity(key, identity)
+	return identity, nil
+}
+
+func (a *TokenReviewAuthenticator) cachedIdentity(key [sha256.Size]byte) *Identity {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.cache[key]
+	if !ok {
+		return nil
+	}
+	if a.now().After(entry.expires) {
+		delete(a.cache, key)
+		return nil
+	}
+	return entry.identity
+}
+
+func (a *TokenReviewAuthenticator) cacheIdentity(key [sha256.Size]byte, identity *Identity) {
+	if a.cacheTTL <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	for cachedKey, entry := range a.cache {
+		if now.After(entry.expires) {
+			delete(a.cache, cachedKey)
+		}
+	}
+	a.cache[key] = cachedReview{identity: identity, expires: now.Add(a.cacheTTL)}
+}
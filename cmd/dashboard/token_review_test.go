@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeTokenReviewClient returns a fake clientset whose TokenReview API authenticates the given tokens, counting the reviews performed.
+func newFakeTokenReviewClient(validTokens map[string]authenticationv1.UserInfo, reviews *int) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "explode" {
+			return true, nil, errors.New("apiserver unavailable")
+		}
+		if user, ok := validTokens[review.Spec.Token]; ok {
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: user}
+		} else {
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: false, Error: "invalid bearer token"}
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+func TestTokenReviewAuthenticator_Authenticate(t *testing.T) {
+	validTokens := map[string]authenticationv1.UserInfo{
+		"monitor-token": {
+			Username: "system:serviceaccount:ship-status:component-monitor",
+			Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:ship-status"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      *Identity
+		expectErr     bool
+	}{
+		{
+			name:          "valid service account token",
+			authorization: "Bearer monitor-token",
+			expected: &Identity{
+				User:   "system:serviceaccount:ship-status:component-monitor",
+				Groups: []string{"system:serviceaccounts", "system:serviceaccounts:ship-status"},
+				Method: AuthMethodTokenReview,
+			},
+		},
+		{
+			name:          "scheme is case insensitive",
+			authorization: "bearer monitor-token",
+			expected: &Identity{
+				User:   "system:serviceaccount:ship-status:component-monitor",
+				Groups: []string{"system:serviceaccounts", "system:serviceaccounts:ship-status"},
+				Method: AuthMethodTokenReview,
+			},
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer wrong-token",
+			expectErr:     true,
+		},
+		{
+			name:          "token review error",
+			authorization: "Bearer explode",
+			expectErr:     true,
+		},
+		{
+			name:     "no authorization header",
+			expected: nil,
+		},
+		{
+			name:          "non-bearer authorization header",
+			authorization: "Basic dXNlcjpwYXNz",
+			expected:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reviews int
+			authenticator := NewTokenReviewAuthenticator(newFakeTokenReviewClient(validTokens, &reviews), nil, time.Minute)
+
+			req := httptest.NewRequest("POST", "/api/components/Prow/Tide/outages", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			identity, err := authenticator.Authenticate(req)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
+
+func TestTokenReviewAuthenticator_Caching(t *testing.T) {
+	validTokens := map[string]authenticationv1.UserInfo{
+		"monitor-token": {Username: "system:serviceaccount:ship-status:component-monitor"},
+	}
+	var reviews int
+	authenticator := NewTokenReviewAuthenticator(newFakeTokenReviewClient(validTokens, &reviews), nil, time.Minute)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	authenticator.now = func() time.Time { return now }
+
+	authenticate := func(token string) error {
+		req := httptest.NewRequest("PATCH", "/api/components/Prow/Tide/outages/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, err := authenticator.Authenticate(req)
+		return err
+	}
+
+	require.NoError(t, authenticate("monitor-token"))
+	require.NoError(t, authenticate("monitor-token"))
+	assert.Equal(t, 1, reviews, "successful review should be served from cache")
+
+	assert.Error(t, authenticate("wrong-token"))
+	assert.Error(t, authenticate("wrong-token"))
+	assert.Equal(t, 3, reviews, "failed reviews should not be cached")
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, authenticate("monitor-token"))
+	assert.Equal(t, 4, reviews, "expired cache entry should be reviewed again")
+}
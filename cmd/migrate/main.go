@@ -36,10 +36,29 @@ func main() {
 
 	log.Info("Running migrations...")
 
-	if err := db.AutoMigrate(&types.Outage{}); err != nil {
+	if err := db.AutoMigrate(&types.Outage{}, &types.OutageReport{}, &types.OutageUpdate{}, &types.Incident{}, &types.IncidentEvent{}, &types.Postmortem{}, &types.APIToken{}, &types.AuditLogEntry{}, &types.IdempotencyKey{}); err != nil {
 		log.WithField("error", err).Fatal("Failed to migrate database")
 	}
 
+	// The audit log must only ever be appended to, so reject any attempt to change or remove entries
+	for _, statement := range []string{
+		`CREATE OR REPLACE FUNCTION reject_audit_log_modification() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_log_entries is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_log_entries_append_only ON audit_log_entries`,
+		`CREATE TRIGGER audit_log_entries_append_only BEFORE UPDATE OR DELETE ON audit_log_entries
+		FOR EACH ROW EXECUTE FUNCTION reject_audit_log_modification()`,
+		`DROP TRIGGER IF EXISTS audit_log_entries_no_truncate ON audit_log_entries`,
+		`CREATE TRIGGER audit_log_entries_no_truncate BEFORE TRUNCATE ON audit_log_entries
+		FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_modification()`,
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			log.WithField("error", err).Fatal("Failed to protect audit log")
+		}
+	}
+
 	log.Info("Migration completed successfully")
 
 	sqlDB, err := db.DB()
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"ship-status-dash/pkg/types"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purgeOutages permanently removes the outages that were soft-deleted before cutoff, together with their reports,
+// updates and postmortems, and returns how many outages were purged. Everything is removed in one transaction, so
+// that a failure never leaves an outage behind without its history, or the history of a purged outage behind.
+func purgeOutages(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&types.Outage{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, model := range []interface{}{&types.OutageUpdate{}, &types.OutageReport{}, &types.Postmortem{}} {
+			if err := tx.Unscoped().Where("outage_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&types.Outage{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
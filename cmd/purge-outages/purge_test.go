@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"ship-status-dash/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestPurgeOutages(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if testing.Short() || dsn == "" {
+		t.Skip("Skipping purge test without a test database")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+
+	createOutage := func(deletedAt time.Time) types.Outage {
+		outage := types.Outage{
+			ComponentName:  "Tide",
+			Severity:       types.SeverityDown,
+			StartTime:      deletedAt.Add(-time.Hour),
+			DiscoveredFrom: "purge-test",
+			CreatedBy:      "purge-test",
+		}
+		require.NoError(t, db.Create(&outage).Error)
+		require.NoError(t, db.Create(&types.OutageUpdate{OutageID: outage.ID, Message: "Investigating", Author: "purge-test"}).Error)
+		require.NoError(t, db.Create(&types.OutageReport{OutageID: outage.ID, Severity: types.SeverityDown, DiscoveredFrom: "purge-test", ReportedBy: "purge-test"}).Error)
+		require.NoError(t, db.Create(&types.Postmortem{OutageID: &outage.ID, Summary: "Tide was down", CreatedBy: "purge-test", UpdatedBy: "purge-test"}).Error)
+		require.NoError(t, db.Unscoped().Model(&outage).Update("deleted_at", deletedAt).Error)
+		return outage
+	}
+	countRows := func(model interface{}, column string, id uint) int64 {
+		var count int64
+		require.NoError(t, db.Unscoped().Model(model).Where(column+" = ?", id).Count(&count).Error)
+		return count
+	}
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	expired := createOutage(cutoff.Add(-time.Hour))
+	retained := createOutage(cutoff.Add(time.Hour))
+	defer func() {
+		_, err := purgeOutages(db, cutoff.Add(2*time.Hour))
+		assert.NoError(t, err)
+	}()
+
+	purged, err := purgeOutages(db, cutoff)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, int64(1))
+
+	assert.Zero(t, countRows(&types.Outage{}, "id", expired.ID))
+	assert.Zero(t, countRows(&types.OutageUpdate{}, "outage_id", expired.ID))
+	assert.Zero(t, countRows(&types.OutageReport{}, "outage_id", expired.ID))
+	assert.Zero(t, countRows(&types.Postmortem{}, "outage_id", expired.ID))
+
+	assert.Equal(t, int64(1), countRows(&types.Outage{}, "id", retained.ID))
+	assert.Equal(t, int64(1), countRows(&types.OutageUpdate{}, "outage_id", retained.ID))
+	assert.Equal(t, int64(1), countRows(&types.OutageReport{}, "outage_id", retained.ID))
+	assert.Equal(t, int64(1), countRows(&types.Postmortem{}, "outage_id", retained.ID))
+}
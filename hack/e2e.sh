@@ -86,7 +86,7 @@ DASHBOARD_PID=""
 DASHBOARD_LOG="/tmp/dashboard-server.log"
 
 # Start dashboard server in background
-go run ./cmd/dashboard --config test/e2e/config.yaml --port $DASHBOARD_PORT --dsn "$DSN" 2> "$DASHBOARD_LOG" &
+go run ./cmd/dashboard --config test/e2e/config.yaml --port $DASHBOARD_PORT --dsn "$DSN" --trusted-proxies "127.0.0.1,::1" 2> "$DASHBOARD_LOG" &
 DASHBOARD_PID=$!
 
 # Wait for server to be ready
@@ -108,7 +108,7 @@ done
 
 echo "Running e2e tests..."
 export TEST_SERVER_PORT="$DASHBOARD_PORT"
-gotestsum ./test/e2e/... -count 1 -p 1
+gotestsum ./test/e2e/... ./cmd/purge-outages/... -count 1 -p 1
 TEST_EXIT_CODE=$?
 
 echo "Stopping dashboard server..."
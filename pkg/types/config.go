@@ -1,8 +1,14 @@
 package types
 
+import "fmt"
+
 // Config contains the application configuration including component definitions.
 type Config struct {
 	Components []Component `json:"components" yaml:"components"`
+	// DefaultRole is granted to every authenticated caller, and defaults to viewer.
+	DefaultRole Role `json:"default_role,omitempty" yaml:"default_role,omitempty"`
+	// RoleBindings grant roles on every component.
+	RoleBindings []RoleBinding `json:"role_bindings,omitempty" yaml:"role_bindings,omitempty"`
 }
 
 // Component represents a top-level system component with sub-components and ownership information.
@@ -13,6 +19,8 @@ type Component struct {
 	SlackChannel  string         `json:"slack_channel" yaml:"slack_channel"`
 	Subcomponents []SubComponent `json:"sub_components" yaml:"sub_components"`
 	Owners        []Owner        `json:"owners" yaml:"owners"`
+	// RoleBindings grant roles on this component only. Owners are implicitly granted the owner role.
+	RoleBindings []RoleBinding `json:"role_bindings,omitempty" yaml:"role_bindings,omitempty"`
 }
 
 func (c *Component) GetSubComponent(subComponentName string) *SubComponent {
@@ -33,7 +41,26 @@ type SubComponent struct {
 }
 
 // Owner represents ownership information for a component, either via Rover group or service account.
+// Service accounts are given as "namespace:name".
 type Owner struct {
 	RoverGroup     string `json:"rover_group,omitempty" yaml:"rover_group,omitempty"`
 	ServiceAccount string `json:"service_account,omitempty" yaml:"service_account,omitempty"`
 }
+
+// ServiceAccountUserPrefix is the prefix Kubernetes uses for the user names of service accounts.
+const ServiceAccountUserPrefix = "system:serviceaccount:"
+
+// ServiceAccountUser returns the Kubernetes user name of the owner's service account, or an empty string if it has none.
+func (o Owner) ServiceAccountUser() string {
+	if o.ServiceAccount == "" {
+		return ""
+	}
+	return ServiceAccountUserPrefix + o.ServiceAccount
+}
+
+func (o Owner) String() string {
+	if o.RoverGroup != "" {
+		return fmt.Sprintf("rover_group %q", o.RoverGroup)
+	}
+	return fmt.Sprintf("service_account %q", o.ServiceAccount)
+}
@@ -0,0 +1,174 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFiles resolves a config path to the list of YAML files it refers to.
+// The path may be a single file, a directory (all *.yaml and *.yml files directly inside it), or a glob pattern.
+func ConfigFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.IsDir():
+		return []string{path}, nil
+	case err == nil && info.IsDir():
+		var files []string
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list config directory %s: %w", path, err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("config directory %s contains no .yaml or .yml files", path)
+		}
+		sort.Strings(files)
+		return files, nil
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to stat config path %s: %w", path, err)
+	}
+
+	if !strings.ContainsAny(path, "*?[") {
+		return nil, errors.New("config file does not exist: " + path)
+	}
+	files, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config glob %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("config glob matched no files: " + path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// globalConfigName is the name, without extension, of the only file that may hold settings that apply to every
+// component when the config is split across several files, so that a team that owns the file of its component
+// cannot grant itself roles on other components.
+const globalConfigName = "global"
+
+// isGlobalConfigFile reports whether a config file may hold global settings.
+func isGlobalConfigFile(file string, files []string) bool {
+	base := filepath.Base(file)
+	return len(files) == 1 || strings.TrimSuffix(base, filepath.Ext(base)) == globalConfigName
+}
+
+// decodeConfig parses a config file, rejecting unknown keys so that misspelled and removed settings are not
+// silently ignored.
+func decodeConfig(data []byte) (Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// LoadConfig reads every file referred to by path and merges their components into a single Config. When there
+// are several files, default_role and role_bindings are only accepted from global.yaml (or global.yml).
+// All problems found are returned together, each prefixed with the file it came from.
+func LoadConfig(path string) (*Config, error) {
+	files, err := ConfigFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &Config{}
+	var errs []error
+	componentSources := make(map[string]string)
+	subComponentSources := make(map[string]string)
+	var defaultRoleSource string
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to read file: %w", file, err))
+			continue
+		}
+
+		config, err := decodeConfig(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to parse file: %w", file, err))
+			continue
+		}
+
+		if !isGlobalConfigFile(file, files) && (config.DefaultRole != "" || len(config.RoleBindings) > 0) {
+			errs = append(errs, fmt.Errorf("%s: default_role and role_bindings can only be set in %s.yaml", file, globalConfigName))
+			config.DefaultRole = ""
+			config.RoleBindings = nil
+		}
+
+		if config.DefaultRole != "" {
+			switch {
+			case !IsValidRole(config.DefaultRole):
+				errs = append(errs, fmt.Errorf("%s: invalid default_role %q", file, config.DefaultRole))
+			case defaultRoleSource != "" && merged.DefaultRole != config.DefaultRole:
+				errs = append(errs, fmt.Errorf("%s: default_role %q conflicts with %q set in %s", file, config.DefaultRole, merged.DefaultRole, defaultRoleSource))
+			default:
+				merged.DefaultRole = config.DefaultRole
+				defaultRoleSource = file
+			}
+		}
+
+		for i, binding := range config.RoleBindings {
+			if err := binding.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: role_bindings[%d]: %w", file, i, err))
+				continue
+			}
+			merged.RoleBindings = append(merged.RoleBindings, binding)
+		}
+
+		for _, component := range config.Components {
+			if component.Name == "" {
+				errs = append(errs, fmt.Errorf("%s: component is missing a name", file))
+				continue
+			}
+			if source, exists := componentSources[component.Name]; exists {
+				errs = append(errs, fmt.Errorf("%s: component %q is already defined in %s", file, component.Name, source))
+				continue
+			}
+			componentSources[component.Name] = file
+
+			for i, binding := range component.RoleBindings {
+				if err := binding.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: component %q role_bindings[%d]: %w", file, component.Name, i, err))
+				}
+			}
+
+			// Outages are stored against the sub-component name alone, so it must be unique across all components
+			for _, subComponent := range component.Subcomponents {
+				if subComponent.Name == "" {
+					errs = append(errs, fmt.Errorf("%s: component %q has a sub-component without a name", file, component.Name))
+					continue
+				}
+				location := fmt.Sprintf("%s (component %q)", file, component.Name)
+				if source, exists := subComponentSources[subComponent.Name]; exists {
+					errs = append(errs, fmt.Errorf("%s: sub-component %q is already defined in %s", location, subComponent.Name, source))
+					continue
+				}
+				subComponentSources[subComponent.Name] = location
+			}
+
+			merged.Components = append(merged.Components, component)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if merged.DefaultRole == "" {
+		merged.DefaultRole = RoleViewer
+	}
+	return merged, nil
+}
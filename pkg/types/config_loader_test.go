@@ -0,0 +1,213 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prowConfig = `components:
+  - name: Prow
+    sub_components:
+      - name: Tide
+      - name: Deck
+    owners:
+      - rover_group: dptp
+`
+
+const sippyConfig = `components:
+  - name: Sippy
+    sub_components:
+      - name: Sippy
+      - name: Sippy-Auth
+    owners:
+      - rover_group: trt
+`
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"prow.yaml":  prowConfig,
+		"sippy.yml":  sippyConfig,
+		"README.md":  "not config",
+		"other.json": "{}",
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "single file",
+			path:     filepath.Join(dir, "prow.yaml"),
+			expected: []string{filepath.Join(dir, "prow.yaml")},
+		},
+		{
+			name:     "directory includes only yaml files",
+			path:     dir,
+			expected: []string{filepath.Join(dir, "prow.yaml"), filepath.Join(dir, "sippy.yml")},
+		},
+		{
+			name:     "glob",
+			path:     filepath.Join(dir, "s*"),
+			expected: []string{filepath.Join(dir, "sippy.yml")},
+		},
+		{
+			name:          "missing file",
+			path:          filepath.Join(dir, "missing.yaml"),
+			expectedError: "config file does not exist",
+		},
+		{
+			name:          "glob without matches",
+			path:          filepath.Join(dir, "*.toml"),
+			expectedError: "config glob matched no files",
+		},
+		{
+			name:          "directory without yaml files",
+			path:          t.TempDir(),
+			expectedError: "contains no .yaml or .yml files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ConfigFiles(tt.path)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, files)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name                string
+		files               map[string]string
+		expectedComponents  []string
+		expectedDefaultRole Role
+		expectedErrors      []string
+	}{
+		{
+			name:               "merges components from all files in order",
+			files:              map[string]string{"a-prow.yaml": prowConfig, "b-sippy.yaml": sippyConfig},
+			expectedComponents: []string{"Prow", "Sippy"},
+		},
+		{
+			name: "duplicate component across files reports both files",
+			files: map[string]string{
+				"a-prow.yaml": prowConfig,
+				"b-prow.yaml": "components:\n  - name: Prow\n    sub_components:\n      - name: Hook\n",
+			},
+			expectedErrors: []string{`b-prow.yaml: component "Prow" is already defined in`, "a-prow.yaml"},
+		},
+		{
+			name: "duplicate sub-component across components",
+			files: map[string]string{
+				"a-prow.yaml":  prowConfig,
+				"b-other.yaml": "components:\n  - name: Other\n    sub_components:\n      - name: Deck\n",
+			},
+			expectedErrors: []string{`b-other.yaml (component "Other"): sub-component "Deck" is already defined in`},
+		},
+		{
+			name: "global settings from the global file",
+			files: map[string]string{
+				"a-prow.yaml": prowConfig,
+				"global.yaml": "default_role: reporter\nrole_bindings:\n  - role: admin\n    groups: [ship-admins]\n",
+			},
+			expectedComponents:  []string{"Prow"},
+			expectedDefaultRole: RoleReporter,
+		},
+		{
+			name: "global settings from a team file",
+			files: map[string]string{
+				"a-prow.yaml":  prowConfig,
+				"b-sippy.yaml": sippyConfig + "role_bindings:\n  - role: admin\n    groups: [trt]\n",
+				"c-other.yaml": "default_role: owner\n",
+			},
+			expectedErrors: []string{
+				"b-sippy.yaml: default_role and role_bindings can only be set in global.yaml",
+				"c-other.yaml: default_role and role_bindings can only be set in global.yaml",
+			},
+		},
+		{
+			name: "conflicting default roles",
+			files: map[string]string{
+				"global.yaml": "default_role: reporter\n",
+				"global.yml":  "default_role: viewer\n",
+			},
+			expectedErrors: []string{`global.yml: default_role "viewer" conflicts with "reporter" set in`},
+		},
+		{
+			name: "removed and unknown keys",
+			files: map[string]string{
+				"a-prow.yaml": prowConfig + "    slack: \"#prow\"\n",
+				"global.yaml": "admins:\n  - ship-admins\n",
+			},
+			expectedErrors: []string{
+				"a-prow.yaml: failed to parse file: yaml: unmarshal errors:\n  line 8: field slack not found",
+				"global.yaml: failed to parse file: yaml: unmarshal errors:\n  line 1: field admins not found",
+			},
+		},
+		{
+			name: "all problems are reported",
+			files: map[string]string{
+				"a-broken.yaml":  "components: [",
+				"b-noname.yaml":  "components:\n  - description: no name\n",
+				"c-sippy.yaml":   sippyConfig,
+				"d-sippy2.yaml":  sippyConfig,
+				"e-unnamed.yaml": "components:\n  - name: Unnamed\n    sub_components:\n      - description: nameless\n",
+				"global.yaml":    "default_role: superuser\nrole_bindings:\n  - role: admin\n",
+				"g-roles.yaml":   "components:\n  - name: Roles\n    role_bindings:\n      - role: owner\n        groups: [a]\n      - role: boss\n        users: [b]\n",
+			},
+			expectedErrors: []string{
+				"a-broken.yaml: failed to parse file",
+				"b-noname.yaml: component is missing a name",
+				`d-sippy2.yaml: component "Sippy" is already defined in`,
+				`e-unnamed.yaml: component "Unnamed" has a sub-component without a name`,
+				`global.yaml: invalid default_role "superuser"`,
+				"global.yaml: role_bindings[0]: role binding must list at least one group, user or service account",
+				`g-roles.yaml: component "Roles" role_bindings[1]: invalid role "boss"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+
+			config, err := LoadConfig(dir)
+			if len(tt.expectedErrors) > 0 {
+				require.Error(t, err)
+				for _, expected := range tt.expectedErrors {
+					assert.Contains(t, err.Error(), expected)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			var names []string
+			for _, component := range config.Components {
+				names = append(names, component.Name)
+			}
+			assert.Equal(t, tt.expectedComponents, names)
+			if tt.expectedDefaultRole != "" {
+				assert.Equal(t, tt.expectedDefaultRole, config.DefaultRole)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// IncidentStatus is the lifecycle state of an incident.
+type IncidentStatus string
+
+const (
+	IncidentStatusInvestigating IncidentStatus = "Investigating"
+	IncidentStatusIdentified    IncidentStatus = "Identified"
+	IncidentStatusMonitoring    IncidentStatus = "Monitoring"
+	IncidentStatusResolved      IncidentStatus = "Resolved"
+)
+
+// IsValidIncidentStatus checks if the provided string is a valid incident status
+func IsValidIncidentStatus(status string) bool {
+	switch IncidentStatus(status) {
+	case IncidentStatusInvestigating, IncidentStatusIdentified, IncidentStatusMonitoring, IncidentStatusResolved:
+		return true
+	default:
+		return false
+	}
+}
+
+// Incident groups outages across components that share a single cause.
+type Incident struct {
+	gorm.Model
+	Title       string          `json:"title" gorm:"column:title;not null"`
+	Description string          `json:"description" gorm:"column:description;type:text"`
+	Status      IncidentStatus  `json:"status" gorm:"column:status;not null;index"`
+	Commander   string          `json:"commander" gorm:"column:commander"`
+	CreatedBy   string          `json:"created_by" gorm:"column:created_by;not null"`
+	ResolvedAt  sql.NullTime    `json:"resolved_at" gorm:"column:resolved_at"`
+	Outages     []Outage        `json:"outages,omitempty" gorm:"foreignKey:IncidentID"`
+	Timeline    []IncidentEvent `json:"timeline,omitempty" gorm:"foreignKey:IncidentID"`
+	// AffectedComponents is derived from the components of the linked outages, and is not stored.
+	AffectedComponents []string `json:"affected_components" gorm:"-"`
+}
+
+// IncidentEventKind describes what an entry on an incident's timeline records.
+type IncidentEventKind string
+
+const (
+	IncidentEventCreated         IncidentEventKind = "created"
+	IncidentEventNote            IncidentEventKind = "note"
+	IncidentEventStatusChanged   IncidentEventKind = "status_changed"
+	IncidentEventCommanderChange IncidentEventKind = "commander_changed"
+	IncidentEventUpdated         IncidentEventKind = "updated"
+	IncidentEventOutageLinked    IncidentEventKind = "outage_linked"
+	IncidentEventOutageUnlinked  IncidentEventKind = "outage_unlinked"
+)
+
+// IncidentEvent is an entry on the timeline of an incident.
+type IncidentEvent struct {
+	gorm.Model
+	IncidentID uint              `json:"incident_id" gorm:"column:incident_id;not null;index"`
+	Kind       IncidentEventKind `json:"kind" gorm:"column:kind;not null"`
+	Message    string            `json:"message" gorm:"column:message;type:text;not null"`
+	Author     string            `json:"author" gorm:"column:author;not null"`
+}
+
+// IncidentReference identifies the incident an active outage belongs to in status responses.
+type IncidentReference struct {
+	ID     uint           `json:"id"`
+	Title  string         `json:"title"`
+	Status IncidentStatus `json:"status"`
+}
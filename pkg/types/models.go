@@ -1,7 +1,11 @@
 package types
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -67,4 +71,154 @@ type Outage struct {
 	ConfirmedBy    *string      `json:"confirmed_by,omitempty" gorm:"column:confirmed_by"`
 	ConfirmedAt    sql.NullTime `json:"confirmed_at" gorm:"column:confirmed_at"`
 	TriageNotes    *string      `json:"triage_notes,omitempty" gorm:"column:triage_notes;type:text"`
+	// DeletedBy and DeletionReason are set when the outage is soft-deleted, and cleared when it is restored.
+	DeletedBy      *string `json:"deleted_by,omitempty" gorm:"column:deleted_by"`
+	DeletionReason *string `json:"deletion_reason,omitempty" gorm:"column:deletion_reason;type:text"`
+	// Version is incremented on every change, and is exposed as the ETag of the outage for optimistic concurrency.
+	Version uint `json:"version" gorm:"column:version;not null;default:1"`
+	// MergedInto is the ID of the outage this one was merged into, in which case it is also soft-deleted.
+	MergedInto *uint          `json:"merged_into,omitempty" gorm:"column:merged_into"`
+	Reports    []OutageReport `json:"reports,omitempty" gorm:"foreignKey:OutageID"`
+	Updates    []OutageUpdate `json:"updates,omitempty" gorm:"foreignKey:OutageID"`
+	// Labels are free-form key/value tags, such as the root cause category of the outage.
+	Labels map[string]string `json:"labels,omitempty" gorm:"column:labels;type:jsonb;serializer:json"`
+	// Links reference related tickets, pull requests and conversations.
+	Links []ExternalLink `json:"links,omitempty" gorm:"column:links;type:jsonb;serializer:json"`
+	// IncidentID is the ID of the incident the outage is grouped into, if any.
+	IncidentID *uint `json:"incident_id,omitempty" gorm:"column:incident_id;index"`
+}
+
+// ExternalLinkType is the kind of resource an external link points to.
+type ExternalLinkType string
+
+const (
+	ExternalLinkJira        ExternalLinkType = "jira"
+	ExternalLinkPullRequest ExternalLinkType = "pull_request"
+	ExternalLinkSlack       ExternalLinkType = "slack"
+	ExternalLinkOther       ExternalLinkType = "other"
+)
+
+// IsValidExternalLinkType checks if the provided string is a valid external link type
+func IsValidExternalLinkType(linkType string) bool {
+	switch ExternalLinkType(linkType) {
+	case ExternalLinkJira, ExternalLinkPullRequest, ExternalLinkSlack, ExternalLinkOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExternalLink references a resource outside the dashboard that is related to an outage.
+type ExternalLink struct {
+	Type  ExternalLinkType `json:"type"`
+	URL   string           `json:"url"`
+	Title string           `json:"title,omitempty"`
+}
+
+// OutageReport is a further report of an active outage, attached to it instead of opening a duplicate outage.
+type OutageReport struct {
+	gorm.Model
+	OutageID       uint     `json:"outage_id" gorm:"column:outage_id;not null;index"`
+	Severity       Severity `json:"severity" gorm:"column:severity;not null"`
+	Description    string   `json:"description" gorm:"column:description;type:text"`
+	DiscoveredFrom string   `json:"discovered_from" gorm:"column:discovered_from;not null"`
+	ReportedBy     string   `json:"reported_by" gorm:"column:reported_by;not null"`
+}
+
+// OutagePhase is the stage of handling an outage that a status update announces.
+type OutagePhase string
+
+const (
+	OutagePhaseInvestigating OutagePhase = "Investigating"
+	OutagePhaseIdentified    OutagePhase = "Identified"
+	OutagePhaseMitigating    OutagePhase = "Mitigating"
+	OutagePhaseMonitoring    OutagePhase = "Monitoring"
+	OutagePhaseResolved      OutagePhase = "Resolved"
+)
+
+// IsValidOutagePhase checks if the provided string is a valid outage phase
+func IsValidOutagePhase(phase string) bool {
+	switch OutagePhase(phase) {
+	case OutagePhaseInvestigating, OutagePhaseIdentified, OutagePhaseMitigating, OutagePhaseMonitoring, OutagePhaseResolved:
+		return true
+	default:
+		return false
+	}
+}
+
+// OutageUpdate is a timestamped status message posted on an outage. Updates are append-only, so that the
+// history of an outage is preserved rather than overwritten like the description and triage notes.
+type OutageUpdate struct {
+	gorm.Model
+	OutageID uint        `json:"outage_id" gorm:"column:outage_id;not null;index"`
+	Message  string      `json:"message" gorm:"column:message;type:text;not null"`
+	Author   string      `json:"author" gorm:"column:author;not null"`
+	Phase    OutagePhase `json:"phase,omitempty" gorm:"column:phase"`
+}
+
+// APIToken is a personal API token that can be presented as a bearer token instead of going through the proxy.
+// Only a hash of the token is stored; the token itself is shown once when it is created. Tokens act as their user
+// without any groups, since group membership cannot be checked again without the proxy, so they only hold roles
+// that are bound to the user directly. Tokens that would hold no more than the viewer role are not created.
+type APIToken struct {
+	gorm.Model
+	Name       string       `json:"name" gorm:"column:name;not null"`
+	User       string       `json:"user" gorm:"column:user_name;not null;index"`
+	TokenHash  string       `json:"-" gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt  time.Time    `json:"expires_at" gorm:"column:expires_at;not null;index"`
+	LastUsedAt sql.NullTime `json:"last_used_at" gorm:"column:last_used_at"`
+	// Components restricts the token to modifying outages of the listed components. An empty list means no restriction.
+	Components []string `json:"components,omitempty" gorm:"column:components;type:text;serializer:json"`
+}
+
+// AuditLogEntry records a mutating API request. Entries are append-only and each one includes the hash of
+// its predecessor, so that modifying or removing an entry breaks the chain and is detectable.
+type AuditLogEntry struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	Timestamp    time.Time `json:"timestamp" gorm:"column:timestamp;not null;index"`
+	Method       string    `json:"method" gorm:"column:method;not null"`
+	Route        string    `json:"route" gorm:"column:route;not null;index"`
+	Path         string    `json:"path" gorm:"column:path;not null;index"`
+	User         string    `json:"user" gorm:"column:user_name;index"`
+	AuthMethod   string    `json:"auth_method" gorm:"column:auth_method"`
+	SourceIP     string    `json:"source_ip" gorm:"column:source_ip;not null"`
+	ForwardedFor string    `json:"forwarded_for,omitempty" gorm:"column:forwarded_for"`
+	BodyHash     string    `json:"body_hash" gorm:"column:body_hash;not null"`
+	StatusCode   int       `json:"status_code" gorm:"column:status_code;not null"`
+	PrevHash     string    `json:"prev_hash" gorm:"column:prev_hash;not null"`
+	Hash         string    `json:"hash" gorm:"column:hash;not null;uniqueIndex"`
+}
+
+// ComputeHash returns the hash of the entry's contents chained to its predecessor's hash.
+func (e *AuditLogEntry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		e.Method,
+		e.Route,
+		e.Path,
+		e.User,
+		e.AuthMethod,
+		e.SourceIP,
+		e.ForwardedFor,
+		e.BodyHash,
+		strconv.Itoa(e.StatusCode),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// IdempotencyKey stores the response to a mutating request made with an Idempotency-Key header, so that retries of
+// the request are answered with the original response instead of being applied again. Keys are scoped to the user.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	User        string `gorm:"column:user_name;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string `gorm:"column:idempotency_key;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Fingerprint string `gorm:"column:fingerprint;not null"`
+	// StatusCode is zero while the original request is still being processed.
+	StatusCode      int               `gorm:"column:status_code;not null;default:0"`
+	ResponseHeaders map[string]string `gorm:"column:response_headers;type:text;serializer:json"`
+	ResponseBody    []byte            `gorm:"column:response_body"`
+	CreatedAt       time.Time
+	ExpiresAt       time.Time `gorm:"column:expires_at;not null;index"`
 }
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Postmortem is the write-up of an outage or an incident. Exactly one of OutageID and IncidentID is set.
+type Postmortem struct {
+	gorm.Model
+	OutageID    *uint                  `json:"outage_id,omitempty" gorm:"column:outage_id;uniqueIndex:idx_postmortems_outage_id,where:deleted_at IS NULL"`
+	IncidentID  *uint                  `json:"incident_id,omitempty" gorm:"column:incident_id;uniqueIndex:idx_postmortems_incident_id,where:deleted_at IS NULL"`
+	Summary     string                 `json:"summary" gorm:"column:summary;type:text"`
+	Impact      string                 `json:"impact" gorm:"column:impact;type:text"`
+	RootCause   string                 `json:"root_cause" gorm:"column:root_cause;type:text"`
+	Timeline    string                 `json:"timeline" gorm:"column:timeline;type:text"`
+	ActionItems []PostmortemActionItem `json:"action_items" gorm:"column:action_items;type:jsonb;serializer:json"`
+	CreatedBy   string                 `json:"created_by" gorm:"column:created_by;not null"`
+	UpdatedBy   string                 `json:"updated_by" gorm:"column:updated_by;not null"`
+}
+
+// PostmortemActionItem is a follow-up task agreed on in a postmortem.
+type PostmortemActionItem struct {
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Done        bool       `json:"done"`
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Role grants a set of permissions on outages, either globally or for a single component.
+type Role string
+
+const (
+	// RoleViewer can read status and outages. Every caller, including anonymous ones, is at least a viewer.
+	RoleViewer Role = "viewer"
+	// RoleReporter can additionally report Suspected outages.
+	RoleReporter Role = "reporter"
+	// RoleOwner can additionally create outages of any severity, update, confirm and triage them.
+	RoleOwner Role = "owner"
+	// RoleAdmin can additionally delete outages and use the administrative endpoints.
+	RoleAdmin Role = "admin"
+)
+
+// Level returns a numeric value for role comparison (higher = more permissions).
+func (r Role) Level() int {
+	switch r {
+	case RoleAdmin:
+		return 4
+	case RoleOwner:
+		return 3
+	case RoleReporter:
+		return 2
+	case RoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValidRole checks if the provided role is one of the defined roles.
+func IsValidRole(role Role) bool {
+	return role.Level() > 0
+}
+
+// RoleBinding grants a role to groups, users and service accounts. Service accounts are given as "namespace:name".
+type RoleBinding struct {
+	Role            Role     `json:"role" yaml:"role"`
+	Groups          []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Users           []string `json:"users,omitempty" yaml:"users,omitempty"`
+	ServiceAccounts []string `json:"service_accounts,omitempty" yaml:"service_accounts,omitempty"`
+}
+
+// Validate checks that the binding grants a known role to at least one subject.
+func (b RoleBinding) Validate() error {
+	if !IsValidRole(b.Role) {
+		return fmt.Errorf("invalid role %q, must be one of: viewer, reporter, owner, admin", b.Role)
+	}
+	if len(b.Groups) == 0 && len(b.Users) == 0 && len(b.ServiceAccounts) == 0 {
+		return errors.New("role binding must list at least one group, user or service account")
+	}
+	return nil
+}
@@ -14,4 +14,6 @@ type ComponentStatus struct {
 	ComponentName string   `json:"component_name"`
 	Status        Status   `json:"status"`
 	ActiveOutages []Outage `json:"active_outages"`
+	// Incidents are the incidents that the active outages are part of.
+	Incidents []IncidentReference `json:"incidents,omitempty"`
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"ship-status-dash/pkg/types"
+	"slices"
 	"testing"
 	"time"
 
@@ -32,8 +33,18 @@ func TestE2E_Dashboard(t *testing.T) {
 	t.Run("Components", testComponents(serverURL))
 	t.Run("ComponentInfo", testComponentInfo(serverURL))
 	t.Run("Outages", testOutages(serverURL))
+	t.Run("Authentication", testAuthentication(serverURL))
+	t.Run("APITokens", testAPITokens(serverURL))
 	t.Run("UpdateOutage", testUpdateOutage(serverURL))
+	t.Run("OptimisticConcurrency", testOptimisticConcurrency(serverURL))
+	t.Run("IdempotencyKeys", testIdempotencyKeys(serverURL))
+	t.Run("OutageUpdates", testOutageUpdates(serverURL))
+	t.Run("LabelsAndLinks", testLabelsAndLinks(serverURL))
+	t.Run("Postmortems", testPostmortems(serverURL))
+	t.Run("DuplicateOutages", testDuplicateOutages(serverURL))
 	t.Run("DeleteOutage", testDeleteOutage(serverURL))
+	t.Run("AuditLog", testAuditLog(serverURL))
+	t.Run("Incidents", testIncidents(serverURL))
 	t.Run("GetOutage", testGetOutage(serverURL))
 	t.Run("SubComponentStatus", testSubComponentStatus(serverURL))
 	t.Run("ComponentStatus", testComponentStatus(serverURL))
@@ -42,6 +53,36 @@ func TestE2E_Dashboard(t *testing.T) {
 	t.Log("All tests passed!")
 }
 
+const testUser = "test-user"
+
+// authTransport sets the identity headers that the dashboard trusts from the authenticating proxy.
+type authTransport struct {
+	user   string
+	groups string
+}
+
+func (a *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Forwarded-User", a.user)
+	req.Header.Set("X-Forwarded-Groups", a.groups)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// clientFor returns a client that authenticates as the given user and comma-separated groups.
+func clientFor(user, groups string) *http.Client {
+	return &http.Client{Transport: &authTransport{user: user, groups: groups}}
+}
+
+// authenticatedClient returns a client that authenticates as a member of the Prow owner group.
+func authenticatedClient() *http.Client {
+	return clientFor(testUser, "dptp")
+}
+
+// adminClient returns a client that authenticates as a member of the admin group.
+func adminClient() *http.Client {
+	return clientFor("test-admin", "ship-admins")
+}
+
 func testHealth(serverURL string) func(*testing.T) {
 	return func(t *testing.T) {
 		resp, err := http.Get(serverURL + "/health")
@@ -135,7 +176,7 @@ func createOutage(t *testing.T, serverURL, componentName, subComponentName strin
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client := authenticatedClient()
 	resp, err := client.Do(req)
 	require.NoError(t, err)
 	defer resp.Body.Close()
@@ -149,12 +190,19 @@ func createOutage(t *testing.T, serverURL, componentName, subComponentName strin
 	return outage
 }
 
+// newDeleteOutageRequest builds a DELETE request for the outage URL that carries the required deletion reason.
+func newDeleteOutageRequest(t *testing.T, outageURL string) *http.Request {
+	req, err := http.NewRequest("DELETE", outageURL, bytes.NewBufferString(`{"reason":"e2e cleanup"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
 // deleteOutage is a helper function to delete an outage for cleanup
 func deleteOutage(t *testing.T, serverURL, componentName, subComponentName string, outageID uint) {
-	req, err := http.NewRequest("DELETE", serverURL+"/api/components/"+componentName+"/"+subComponentName+"/outages/"+fmt.Sprintf("%d", outageID), nil)
-	require.NoError(t, err)
+	req := newDeleteOutageRequest(t, serverURL+"/api/components/"+componentName+"/"+subComponentName+"/outages/"+fmt.Sprintf("%d", outageID))
 
-	client := &http.Client{}
+	client := adminClient()
 	resp, err := client.Do(req)
 	require.NoError(t, err)
 	defer resp.Body.Close()
@@ -181,7 +229,7 @@ func testOutages(serverURL string) func(*testing.T) {
 			require.NoError(t, err)
 			req.Header.Set("Content-Type", "application/json")
 
-			client := &http.Client{}
+			client := authenticatedClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
@@ -216,7 +264,7 @@ func testOutages(serverURL string) func(*testing.T) {
 			require.NoError(t, err)
 			req.Header.Set("Content-Type", "application/json")
 
-			client := &http.Client{}
+			client := authenticatedClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
@@ -241,7 +289,7 @@ func testOutages(serverURL string) func(*testing.T) {
 			require.NoError(t, err)
 			req.Header.Set("Content-Type", "application/json")
 
-			client := &http.Client{}
+			client := authenticatedClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
@@ -287,7 +335,7 @@ func testOutages(serverURL string) func(*testing.T) {
 			// Create outages for different sub-components
 			tideOutage1 := createOutage(t, serverURL, "Prow", "Tide")
 			defer deleteOutage(t, serverURL, "Prow", "Tide", tideOutage1.ID)
-			tideOutage2 := createOutage(t, serverURL, "Prow", "Tide")
+			tideOutage2 := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
 			defer deleteOutage(t, serverURL, "Prow", "Tide", tideOutage2.ID)
 			deckOutage := createOutage(t, serverURL, "Prow", "Deck")
 			defer deleteOutage(t, serverURL, "Prow", "Deck", deckOutage.ID)
@@ -331,6 +379,194 @@ func testOutages(serverURL string) func(*testing.T) {
 	}
 }
 
+func testAuthentication(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		outagePayload := map[string]interface{}{
+			"severity":        string(types.SeverityDown),
+			"start_time":      time.Now().UTC().Format(time.RFC3339),
+			"description":     "Test outage",
+			"discovered_from": "e2e-test",
+			"created_by":      "someone-else",
+		}
+		payloadBytes, err := json.Marshal(outagePayload)
+		require.NoError(t, err)
+
+		t.Run("POST without identity returns 401", func(t *testing.T) {
+			resp, err := http.Post(serverURL+"/api/components/Prow/Tide/outages", "application/json", bytes.NewBuffer(payloadBytes))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+
+		t.Run("POST takes created_by from identity rather than the body", func(t *testing.T) {
+			resp, err := authenticatedClient().Post(serverURL+"/api/components/Prow/Tide/outages", "application/json", bytes.NewBuffer(payloadBytes))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var outage types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&outage)
+			require.NoError(t, err)
+			defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			assert.Equal(t, testUser, outage.CreatedBy)
+		})
+
+		t.Run("POST of a Down outage by a non-owner returns 403 naming the allowed owners", func(t *testing.T) {
+			resp, err := clientFor("outsider", "trt").Post(serverURL+"/api/components/Prow/Tide/outages", "application/json", bytes.NewBuffer(payloadBytes))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+			var errorResponse map[string]string
+			err = json.NewDecoder(resp.Body).Decode(&errorResponse)
+			require.NoError(t, err)
+			assert.Contains(t, errorResponse["error"], `rover_group "dptp"`)
+		})
+
+		t.Run("non-owner can report a Suspected outage", func(t *testing.T) {
+			outage := createOutageWithClient(t, clientFor("outsider", "trt"), serverURL, "Prow", "Tide", string(types.SeveritySuspected))
+			defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			assert.Equal(t, "outsider", outage.CreatedBy)
+		})
+
+		t.Run("owner cannot delete outages", func(t *testing.T) {
+			outage := createOutage(t, serverURL, "Prow", "Tide")
+			defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			req, err := http.NewRequest("DELETE", serverURL+"/api/components/Prow/Tide/outages/"+fmt.Sprintf("%d", outage.ID), nil)
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+
+		t.Run("DELETE without identity returns 401", func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", serverURL+"/api/components/Prow/Tide/outages/1", nil)
+			require.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
+// bearerTransport authenticates requests with a bearer token.
+type bearerTransport struct {
+	token string
+}
+
+func (b *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func testAPITokens(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		payloadBytes, err := json.Marshal(map[string]interface{}{
+			"name":       "e2e-token",
+			"components": []string{"Prow"},
+		})
+		require.NoError(t, err)
+
+		resp, err := authenticatedClient().Post(serverURL+"/api/tokens", "application/json", bytes.NewBuffer(payloadBytes))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created struct {
+			Token    string         `json:"token"`
+			APIToken types.APIToken `json:"api_token"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		require.NoError(t, err)
+		require.NotEmpty(t, created.Token)
+		assert.Equal(t, testUser, created.APIToken.User)
+		assert.Equal(t, []string{"Prow"}, created.APIToken.Components)
+
+		tokenClient := &http.Client{Transport: &bearerTransport{token: created.Token}}
+
+		t.Run("token authenticates outage creation", func(t *testing.T) {
+			outage := createOutageWithClient(t, tokenClient, serverURL, "Prow", "Tide", string(types.SeverityDown))
+			defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			assert.Equal(t, testUser, outage.CreatedBy)
+		})
+
+		t.Run("token does not hold the roles of its creator's groups", func(t *testing.T) {
+			groupOwner := clientFor("group-owner", "dptp")
+			resp, err := groupOwner.Post(serverURL+"/api/tokens", "application/json", bytes.NewBuffer(payloadBytes))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var groupToken struct {
+				Token string `json:"token"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&groupToken))
+
+			outagePayload, err := json.Marshal(map[string]interface{}{
+				"severity":        string(types.SeverityDown),
+				"start_time":      time.Now().UTC().Format(time.RFC3339),
+				"description":     "Outage by a group owner's token",
+				"discovered_from": "e2e-test",
+			})
+			require.NoError(t, err)
+			groupTokenClient := &http.Client{Transport: &bearerTransport{token: groupToken.Token}}
+			resp, err = groupTokenClient.Post(serverURL+"/api/components/Prow/Tide/outages", "application/json", bytes.NewBuffer(outagePayload))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+
+		t.Run("token cannot create other tokens", func(t *testing.T) {
+			resp, err := tokenClient.Post(serverURL+"/api/tokens", "application/json", bytes.NewBuffer(payloadBytes))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+
+		t.Run("token is listed without its hash", func(t *testing.T) {
+			resp, err := authenticatedClient().Get(serverURL + "/api/tokens")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), "e2e-token")
+			assert.NotContains(t, string(body), created.Token)
+			assert.NotContains(t, string(body), "token_hash")
+		})
+
+		t.Run("revoked token is rejected", func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/tokens/%d", serverURL, created.APIToken.ID), nil)
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+			resp, err = tokenClient.Get(serverURL + "/api/tokens")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
 func testUpdateOutage(serverURL string) func(*testing.T) {
 	return func(t *testing.T) {
 		// Create an outage to update
@@ -341,7 +577,8 @@ func testUpdateOutage(serverURL string) func(*testing.T) {
 		updatePayload := map[string]interface{}{
 			"severity":     string(types.SeverityDegraded),
 			"description":  "Updated description",
-			"resolved_by":  "test-resolver",
+			"end_time":     time.Now().UTC().Format(time.RFC3339),
+			"resolved_by":  "ignored-resolver",
 			"triage_notes": "Updated triage notes",
 		}
 
@@ -355,7 +592,7 @@ func testUpdateOutage(serverURL string) func(*testing.T) {
 		require.NoError(t, err)
 		updateReq.Header.Set("Content-Type", "application/json")
 
-		client := &http.Client{}
+		client := authenticatedClient()
 		updateResp, err := client.Do(updateReq)
 		require.NoError(t, err)
 		defer updateResp.Body.Close()
@@ -375,7 +612,8 @@ func testUpdateOutage(serverURL string) func(*testing.T) {
 		assert.Equal(t, createdOutage.ID, updatedOutage.ID)
 		assert.Equal(t, string(types.SeverityDegraded), string(updatedOutage.Severity))
 		assert.Equal(t, "Updated description", updatedOutage.Description)
-		assert.Equal(t, "test-resolver", *updatedOutage.ResolvedBy)
+		require.NotNil(t, updatedOutage.ResolvedBy)
+		assert.Equal(t, testUser, *updatedOutage.ResolvedBy) // Taken from the authenticated identity, not the body
 		assert.Equal(t, "Updated triage notes", *updatedOutage.TriageNotes)
 		assert.WithinDuration(t, createdOutage.StartTime.UTC(), updatedOutage.StartTime.UTC(), time.Second) // Should remain unchanged
 		assert.Equal(t, createdOutage.CreatedBy, updatedOutage.CreatedBy)                                   // Should remain unchanged
@@ -432,6 +670,508 @@ func testUpdateOutage(serverURL string) func(*testing.T) {
 	}
 }
 
+func testOptimisticConcurrency(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		createdOutage := createOutage(t, serverURL, "Prow", "Tide")
+		defer deleteOutage(t, serverURL, "Prow", "Tide", createdOutage.ID)
+		outageURL := fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, createdOutage.ID)
+
+		patch := func(t *testing.T, ifMatch, description string) *http.Response {
+			payload, err := json.Marshal(map[string]string{"description": description})
+			require.NoError(t, err)
+			req, err := http.NewRequest("PATCH", outageURL, bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp, err := http.Get(outageURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		etag := resp.Header.Get("ETag")
+		require.Equal(t, `"1"`, etag)
+
+		t.Run("PATCH with the current ETag succeeds and returns the next one", func(t *testing.T) {
+			resp := patch(t, etag, "first triager")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+			var outage types.Outage
+			err := json.NewDecoder(resp.Body).Decode(&outage)
+			require.NoError(t, err)
+			assert.Equal(t, uint(2), outage.Version)
+			assert.Equal(t, "first triager", outage.Description)
+		})
+
+		t.Run("PATCH with a stale ETag returns 412 and changes nothing", func(t *testing.T) {
+			resp := patch(t, etag, "second triager")
+			resp.Body.Close()
+			assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+			resp, err := http.Get(outageURL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var outage types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&outage)
+			require.NoError(t, err)
+			assert.Equal(t, "first triager", outage.Description)
+		})
+
+		t.Run("DELETE with a stale ETag returns 412", func(t *testing.T) {
+			req := newDeleteOutageRequest(t, outageURL)
+			req.Header.Set("If-Match", etag)
+			resp, err := adminClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+		})
+	}
+}
+
+func testIdempotencyKeys(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		key := fmt.Sprintf("e2e-%d", time.Now().UnixNano())
+		// The whole body is fingerprinted, so retries must send exactly the same start_time
+		post := func(t *testing.T, description string) *http.Response {
+			payload, err := json.Marshal(map[string]interface{}{
+				"severity":        string(types.SeverityDown),
+				"start_time":      "2025-01-01T00:00:00Z",
+				"description":     description,
+				"discovered_from": "e2e-test",
+			})
+			require.NoError(t, err)
+			req, err := http.NewRequest("POST", serverURL+"/api/components/Prow/Tide/outages", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", key)
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := post(t, "retried outage")
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created types.Outage
+		err := json.NewDecoder(resp.Body).Decode(&created)
+		require.NoError(t, err)
+		defer deleteOutage(t, serverURL, "Prow", "Tide", created.ID)
+
+		t.Run("retry returns the original response", func(t *testing.T) {
+			resp := post(t, "retried outage")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+
+			var replayed types.Outage
+			err := json.NewDecoder(resp.Body).Decode(&replayed)
+			require.NoError(t, err)
+			assert.Equal(t, created.ID, replayed.ID)
+		})
+
+		t.Run("reusing the key with a different body returns 422", func(t *testing.T) {
+			resp := post(t, "a different outage")
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		})
+	}
+}
+
+func testOutageUpdates(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		outage := createOutage(t, serverURL, "Prow", "Tide")
+		defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+		outageURL := fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, outage.ID)
+
+		postUpdate := func(t *testing.T, client *http.Client, message, phase string) *http.Response {
+			payload, err := json.Marshal(map[string]string{"message": message, "phase": phase})
+			require.NoError(t, err)
+			resp, err := client.Post(outageURL+"/updates", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			return resp
+		}
+
+		t.Run("updates are appended in order", func(t *testing.T) {
+			for _, phase := range []string{"Identified", "Mitigating"} {
+				resp := postUpdate(t, authenticatedClient(), "Moving to "+phase, phase)
+				resp.Body.Close()
+				require.Equal(t, http.StatusCreated, resp.StatusCode)
+			}
+
+			resp, err := http.Get(outageURL + "/updates")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var updates []types.OutageUpdate
+			err = json.NewDecoder(resp.Body).Decode(&updates)
+			require.NoError(t, err)
+			require.Len(t, updates, 2)
+			assert.Equal(t, types.OutagePhaseIdentified, updates[0].Phase)
+			assert.Equal(t, types.OutagePhaseMitigating, updates[1].Phase)
+			assert.Equal(t, testUser, updates[1].Author)
+		})
+
+		t.Run("updates are included in the outage", func(t *testing.T) {
+			resp, err := http.Get(outageURL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var fetched types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&fetched)
+			require.NoError(t, err)
+			require.Len(t, fetched.Updates, 2)
+			assert.Equal(t, "Moving to Identified", fetched.Updates[0].Message)
+		})
+
+		t.Run("updates are included in outage lists and status", func(t *testing.T) {
+			var listed []types.Outage
+			resp, err := http.Get(serverURL + "/api/components/Prow/Tide/outages")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			err = json.NewDecoder(resp.Body).Decode(&listed)
+			require.NoError(t, err)
+			index := slices.IndexFunc(listed, func(o types.Outage) bool { return o.ID == outage.ID })
+			require.NotEqual(t, -1, index)
+			assert.Len(t, listed[index].Updates, 2)
+
+			var status types.ComponentStatus
+			resp, err = http.Get(serverURL + "/api/status/Prow/Tide")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			err = json.NewDecoder(resp.Body).Decode(&status)
+			require.NoError(t, err)
+			index = slices.IndexFunc(status.ActiveOutages, func(o types.Outage) bool { return o.ID == outage.ID })
+			require.NotEqual(t, -1, index)
+			assert.Len(t, status.ActiveOutages[index].Updates, 2)
+		})
+
+		t.Run("update without a message returns 400", func(t *testing.T) {
+			resp := postUpdate(t, authenticatedClient(), "", "Monitoring")
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("non-owners cannot post updates", func(t *testing.T) {
+			resp := postUpdate(t, clientFor("test-reporter", ""), "Still broken", "")
+			resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+	}
+}
+
+func testLabelsAndLinks(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		// A unique value keeps the counts independent of outages left behind by earlier runs
+		cause := fmt.Sprintf("e2e-%d", time.Now().UnixNano())
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"severity":        string(types.SeverityDown),
+			"start_time":      time.Now().UTC().Format(time.RFC3339),
+			"description":     "Labelled outage",
+			"discovered_from": "e2e-test",
+			"labels":          map[string]string{"cause": cause},
+			"links":           []types.ExternalLink{{Type: types.ExternalLinkJira, URL: "https://issues.example.com/browse/DPTP-1"}},
+		})
+		require.NoError(t, err)
+		resp, err := authenticatedClient().Post(serverURL+"/api/components/Prow/Tide/outages?on_duplicate=create", "application/json", bytes.NewBuffer(payload))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var labelled types.Outage
+		err = json.NewDecoder(resp.Body).Decode(&labelled)
+		require.NoError(t, err)
+		defer deleteOutage(t, serverURL, "Prow", "Tide", labelled.ID)
+		assert.Equal(t, map[string]string{"cause": cause}, labelled.Labels)
+		require.Len(t, labelled.Links, 1)
+
+		unlabelled := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+		defer deleteOutage(t, serverURL, "Prow", "Tide", unlabelled.ID)
+
+		listOutages := func(t *testing.T, query string) []types.Outage {
+			resp, err := http.Get(serverURL + "/api/components/Prow/outages?" + query)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var outages []types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&outages)
+			require.NoError(t, err)
+			return outages
+		}
+
+		t.Run("outages can be filtered by label", func(t *testing.T) {
+			outages := listOutages(t, "label=cause="+cause)
+			require.Len(t, outages, 1)
+			assert.Equal(t, labelled.ID, outages[0].ID)
+		})
+
+		t.Run("PATCH replaces the labels", func(t *testing.T) {
+			body := fmt.Sprintf(`{"labels":{"cause":%q,"infra":""}}`, cause)
+			req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, unlabelled.ID), bytes.NewBufferString(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			assert.Len(t, listOutages(t, "label=cause="+cause), 2)
+			assert.Len(t, listOutages(t, "label=cause="+cause+"&label=infra"), 1)
+		})
+
+		t.Run("label report counts outages by value", func(t *testing.T) {
+			resp, err := http.Get(serverURL + "/api/reports/labels?key=cause&component=Prow")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var counts []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+				Count int64  `json:"count"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&counts)
+			require.NoError(t, err)
+			found := false
+			for _, count := range counts {
+				if count.Value == cause {
+					found = true
+					assert.Equal(t, "cause", count.Key)
+					assert.Equal(t, int64(2), count.Count)
+				}
+			}
+			assert.True(t, found)
+		})
+
+		t.Run("invalid link is rejected", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, unlabelled.ID), bytes.NewBufferString(`{"links":[{"type":"jira","url":"not a url"}]}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func testPostmortems(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		outage := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+		defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+		outageURL := fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, outage.ID)
+
+		req, err := http.NewRequest(http.MethodPatch, outageURL, bytes.NewBufferString(fmt.Sprintf(`{"end_time":%q}`, time.Now().UTC().Format(time.RFC3339))))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := authenticatedClient().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		missingPostmortem := func(t *testing.T) bool {
+			resp, err := http.Get(serverURL + "/api/reports/missing-postmortems?component=Prow")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var outages []types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&outages)
+			require.NoError(t, err)
+			for _, missing := range outages {
+				if missing.ID == outage.ID {
+					return true
+				}
+			}
+			return false
+		}
+
+		t.Run("resolved Down outage is reported as missing a postmortem", func(t *testing.T) {
+			assert.True(t, missingPostmortem(t))
+		})
+
+		t.Run("draft is generated from the outage", func(t *testing.T) {
+			resp, err := http.Get(outageURL + "/postmortem/draft")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/markdown; charset=utf-8", resp.Header.Get("Content-Type"))
+
+			draft, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(draft), fmt.Sprintf("# Postmortem: Prow/Tide outage %d", outage.ID))
+			assert.Contains(t, string(draft), "Outage resolved by "+testUser)
+		})
+
+		t.Run("creating a postmortem satisfies the requirement", func(t *testing.T) {
+			payload, err := json.Marshal(map[string]interface{}{
+				"outage_id":    outage.ID,
+				"summary":      "Tide stopped merging",
+				"action_items": []map[string]string{{"description": "Alert on merge rate", "owner": testUser}},
+			})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(serverURL+"/api/postmortems", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+			var postmortem types.Postmortem
+			err = json.NewDecoder(resp.Body).Decode(&postmortem)
+			require.NoError(t, err)
+			assert.Equal(t, testUser, postmortem.CreatedBy)
+			require.Len(t, postmortem.ActionItems, 1)
+			assert.False(t, missingPostmortem(t))
+
+			resp, err = authenticatedClient().Post(serverURL+"/api/postmortems", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		})
+	}
+}
+
+func testDuplicateOutages(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		existing := createOutageWithSeverity(t, serverURL, "Prow", "Tide", string(types.SeverityDegraded))
+		defer deleteOutage(t, serverURL, "Prow", "Tide", existing.ID)
+
+		report := func(t *testing.T, onDuplicate string) *http.Response {
+			payload, err := json.Marshal(map[string]interface{}{
+				"severity":        string(types.SeverityDown),
+				"start_time":      time.Now().UTC().Format(time.RFC3339),
+				"description":     "Tide is not merging",
+				"discovered_from": "e2e-test",
+			})
+			require.NoError(t, err)
+			outagesURL := serverURL + "/api/components/Prow/Tide/outages"
+			if onDuplicate != "" {
+				outagesURL += "?on_duplicate=" + onDuplicate
+			}
+			resp, err := authenticatedClient().Post(outagesURL, "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			return resp
+		}
+
+		t.Run("duplicate is rejected by default with the existing outage", func(t *testing.T) {
+			resp := report(t, "")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusConflict, resp.StatusCode)
+			assert.Equal(t, fmt.Sprintf("/api/components/Prow/Tide/outages/%d", existing.ID), resp.Header.Get("Location"))
+
+			var conflict struct {
+				ExistingOutage types.Outage `json:"existing_outage"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&conflict)
+			require.NoError(t, err)
+			assert.Equal(t, existing.ID, conflict.ExistingOutage.ID)
+		})
+
+		t.Run("duplicate can be attached to the existing outage", func(t *testing.T) {
+			resp := report(t, "attach")
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var outage types.Outage
+			err := json.NewDecoder(resp.Body).Decode(&outage)
+			require.NoError(t, err)
+			assert.Equal(t, existing.ID, outage.ID)
+			assert.Equal(t, types.SeverityDegraded, outage.Severity)
+			require.Len(t, outage.Reports, 1)
+			assert.Equal(t, types.SeverityDown, outage.Reports[0].Severity)
+			assert.Equal(t, testUser, outage.Reports[0].ReportedBy)
+		})
+
+		t.Run("invalid policy returns 400", func(t *testing.T) {
+			resp := report(t, "ignore")
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("merge folds outages into the earliest one", func(t *testing.T) {
+			separate := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+
+			payload, err := json.Marshal(map[string]interface{}{"outage_ids": []uint{separate.ID, existing.ID}})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(serverURL+"/api/components/Prow/Tide/outages/merge", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var merged types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&merged)
+			require.NoError(t, err)
+			assert.Equal(t, existing.ID, merged.ID)
+			assert.Equal(t, types.SeverityDown, merged.Severity)
+			assert.Len(t, merged.Reports, 1)
+
+			resp, err = http.Get(fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, separate.ID))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+			resp, err = adminClient().Post(fmt.Sprintf("%s/api/admin/outages/deleted/%d/restore", serverURL, separate.ID), "application/json", nil)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		})
+
+		createPostmortem := func(t *testing.T, outageID uint) {
+			payload, err := json.Marshal(map[string]interface{}{"outage_id": outageID, "summary": "Tide stopped merging"})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(serverURL+"/api/postmortems", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+		merge := func(t *testing.T, outageIDs ...uint) *http.Response {
+			payload, err := json.Marshal(map[string]interface{}{"outage_ids": outageIDs, "into": existing.ID})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(serverURL+"/api/components/Prow/Tide/outages/merge", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			return resp
+		}
+
+		t.Run("merge moves the postmortem of a merged outage", func(t *testing.T) {
+			separate := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+			createPostmortem(t, separate.ID)
+
+			resp := merge(t, existing.ID, separate.ID)
+			resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			resp, err := http.Get(fmt.Sprintf("%s/api/postmortems?outage_id=%d", serverURL, existing.ID))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var postmortems []types.Postmortem
+			err = json.NewDecoder(resp.Body).Decode(&postmortems)
+			require.NoError(t, err)
+			assert.Len(t, postmortems, 1)
+		})
+
+		t.Run("merge of outages that both have a postmortem is rejected", func(t *testing.T) {
+			separate := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+			defer deleteOutage(t, serverURL, "Prow", "Tide", separate.ID)
+			createPostmortem(t, separate.ID)
+
+			resp := merge(t, existing.ID, separate.ID)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		})
+	}
+}
+
 func testDeleteOutage(serverURL string) func(*testing.T) {
 	return func(t *testing.T) {
 		t.Run("DELETE existing outage succeeds", func(t *testing.T) {
@@ -459,10 +1199,9 @@ func testDeleteOutage(serverURL string) func(*testing.T) {
 		})
 
 		t.Run("DELETE non-existent outage returns 404", func(t *testing.T) {
-			req, err := http.NewRequest("DELETE", serverURL+"/api/components/Prow/Tide/outages/99999", nil)
-			require.NoError(t, err)
+			req := newDeleteOutageRequest(t, serverURL+"/api/components/Prow/Tide/outages/99999")
 
-			client := &http.Client{}
+			client := adminClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
@@ -474,7 +1213,7 @@ func testDeleteOutage(serverURL string) func(*testing.T) {
 			req, err := http.NewRequest("DELETE", serverURL+"/api/components/NonExistentComponent/Tide/outages/1", nil)
 			require.NoError(t, err)
 
-			client := &http.Client{}
+			client := adminClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
@@ -486,13 +1225,253 @@ func testDeleteOutage(serverURL string) func(*testing.T) {
 			req, err := http.NewRequest("DELETE", serverURL+"/api/components/Prow/NonExistentSub/outages/1", nil)
 			require.NoError(t, err)
 
-			client := &http.Client{}
+			client := adminClient()
 			resp, err := client.Do(req)
 			require.NoError(t, err)
 			defer resp.Body.Close()
 
 			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 		})
+
+		t.Run("DELETE without a reason returns 400", func(t *testing.T) {
+			outage := createOutage(t, serverURL, "Prow", "Tide")
+			defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, outage.ID),
+				bytes.NewBufferString(`{"reason":"  "}`))
+			require.NoError(t, err)
+			resp, err := adminClient().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("deleted outage can be inspected and restored", func(t *testing.T) {
+			outage := createOutage(t, serverURL, "Prow", "Tide")
+			deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+			resp, err := adminClient().Get(serverURL + "/api/admin/outages/deleted?component=Prow")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var deleted []types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&deleted)
+			require.NoError(t, err)
+			var found *types.Outage
+			for i := range deleted {
+				if deleted[i].ID == outage.ID {
+					found = &deleted[i]
+				}
+			}
+			require.NotNil(t, found, "Deleted outage should be listed")
+			require.NotNil(t, found.DeletedBy)
+			assert.Equal(t, "test-admin", *found.DeletedBy)
+			require.NotNil(t, found.DeletionReason)
+			assert.Equal(t, "e2e cleanup", *found.DeletionReason)
+
+			resp, err = authenticatedClient().Post(fmt.Sprintf("%s/api/admin/outages/deleted/%d/restore", serverURL, outage.ID), "application/json", nil)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+			resp, err = adminClient().Post(fmt.Sprintf("%s/api/admin/outages/deleted/%d/restore", serverURL, outage.ID), "application/json", nil)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var restored types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&restored)
+			require.NoError(t, err)
+			assert.Equal(t, outage.ID, restored.ID)
+			assert.Nil(t, restored.DeletedBy)
+			assert.Nil(t, restored.DeletionReason)
+
+			resp, err = http.Get(fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, outage.ID))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+		})
+	}
+}
+
+func testAuditLog(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		outage := createOutage(t, serverURL, "Prow", "Deck")
+		deleteOutage(t, serverURL, "Prow", "Deck", outage.ID)
+		deletePath := fmt.Sprintf("/api/components/Prow/Deck/outages/%d", outage.ID)
+
+		t.Run("outage deletion is recorded with the identity of the caller", func(t *testing.T) {
+			resp, err := adminClient().Get(serverURL + "/api/admin/audit?method=DELETE&path=" + deletePath)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var entries []types.AuditLogEntry
+			err = json.NewDecoder(resp.Body).Decode(&entries)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "test-admin", entries[0].User)
+			assert.Equal(t, "/api/components/{componentName}/{subComponentName}/outages/{outageId:[0-9]+}", entries[0].Route)
+			assert.Equal(t, http.StatusNoContent, entries[0].StatusCode)
+			assert.NotEmpty(t, entries[0].SourceIP)
+			assert.NotEmpty(t, entries[0].Hash)
+		})
+
+		t.Run("rejected requests are recorded", func(t *testing.T) {
+			resp, err := http.Post(serverURL+"/api/components/Prow/Deck/outages", "application/json", bytes.NewBufferString("{}"))
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+			resp, err = adminClient().Get(serverURL + "/api/admin/audit?method=POST&limit=1")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var entries []types.AuditLogEntry
+			err = json.NewDecoder(resp.Body).Decode(&entries)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Empty(t, entries[0].User)
+			assert.Equal(t, http.StatusUnauthorized, entries[0].StatusCode)
+		})
+
+		t.Run("hash chain verifies", func(t *testing.T) {
+			resp, err := adminClient().Get(serverURL + "/api/admin/audit/verify")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var verification struct {
+				Valid          bool `json:"valid"`
+				EntriesChecked int  `json:"entries_checked"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&verification)
+			require.NoError(t, err)
+			assert.True(t, verification.Valid)
+			assert.Positive(t, verification.EntriesChecked)
+		})
+
+		t.Run("non-admins cannot read the audit log", func(t *testing.T) {
+			resp, err := authenticatedClient().Get(serverURL + "/api/admin/audit")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+	}
+}
+
+func testIncidents(serverURL string) func(*testing.T) {
+	return func(t *testing.T) {
+		outage := createOutage(t, serverURL, "Prow", "Tide")
+		defer deleteOutage(t, serverURL, "Prow", "Tide", outage.ID)
+
+		payload, err := json.Marshal(map[string]interface{}{"title": "Prow is degraded", "commander": testUser})
+		require.NoError(t, err)
+		resp, err := authenticatedClient().Post(serverURL+"/api/incidents", "application/json", bytes.NewBuffer(payload))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var incident types.Incident
+		err = json.NewDecoder(resp.Body).Decode(&incident)
+		require.NoError(t, err)
+		assert.Equal(t, types.IncidentStatusInvestigating, incident.Status)
+		assert.Equal(t, testUser, incident.CreatedBy)
+		assert.Empty(t, incident.AffectedComponents)
+		incidentURL := fmt.Sprintf("%s/api/incidents/%d", serverURL, incident.ID)
+		defer func() {
+			req, err := http.NewRequest(http.MethodDelete, incidentURL, nil)
+			require.NoError(t, err)
+			resp, err := adminClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		}()
+
+		t.Run("linked outage determines the affected components", func(t *testing.T) {
+			payload, err := json.Marshal(map[string]interface{}{"outage_id": outage.ID})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(incidentURL+"/outages", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var linked types.Incident
+			err = json.NewDecoder(resp.Body).Decode(&linked)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Prow"}, linked.AffectedComponents)
+			require.Len(t, linked.Outages, 1)
+			assert.Equal(t, outage.ID, linked.Outages[0].ID)
+		})
+
+		t.Run("outage linked to an incident cannot open another one", func(t *testing.T) {
+			payload, err := json.Marshal(map[string]interface{}{"title": "Tide is down", "outage_ids": []uint{outage.ID}})
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Post(serverURL+"/api/incidents", "application/json", bytes.NewBuffer(payload))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		})
+
+		t.Run("component status references the incident", func(t *testing.T) {
+			resp, err := http.Get(serverURL + "/api/status/Prow")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var status types.ComponentStatus
+			err = json.NewDecoder(resp.Body).Decode(&status)
+			require.NoError(t, err)
+			assert.Contains(t, status.Incidents, types.IncidentReference{ID: incident.ID, Title: "Prow is degraded", Status: types.IncidentStatusInvestigating})
+		})
+
+		t.Run("status changes are recorded on the timeline", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPatch, incidentURL, bytes.NewBufferString(`{"status":"Identified"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var updated types.Incident
+			err = json.NewDecoder(resp.Body).Decode(&updated)
+			require.NoError(t, err)
+			assert.Equal(t, types.IncidentStatusIdentified, updated.Status)
+			require.NotEmpty(t, updated.Timeline)
+			last := updated.Timeline[len(updated.Timeline)-1]
+			assert.Equal(t, types.IncidentEventStatusChanged, last.Kind)
+			assert.Equal(t, testUser, last.Author)
+		})
+
+		t.Run("reporters cannot manage incidents", func(t *testing.T) {
+			resp, err := clientFor("test-reporter", "").Post(serverURL+"/api/incidents", "application/json", bytes.NewBufferString(`{"title":"nope"}`))
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+
+		t.Run("unlinked outage no longer references the incident", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/outages/%d", incidentURL, outage.ID), nil)
+			require.NoError(t, err)
+			resp, err := authenticatedClient().Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+			resp, err = http.Get(fmt.Sprintf("%s/api/components/Prow/Tide/outages/%d", serverURL, outage.ID))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var unlinked types.Outage
+			err = json.NewDecoder(resp.Body).Decode(&unlinked)
+			require.NoError(t, err)
+			assert.Nil(t, unlinked.IncidentID)
+		})
 	}
 }
 
@@ -605,7 +1584,7 @@ func testSubComponentStatus(serverURL string) func(*testing.T) {
 			defer deleteOutage(t, serverURL, "Prow", "Tide", degradedOutage.ID)
 
 			// Create a Down outage for Tide
-			downOutage := createOutageWithSeverity(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
+			downOutage := createSeparateOutage(t, serverURL, "Prow", "Tide", string(types.SeverityDown))
 			defer deleteOutage(t, serverURL, "Prow", "Tide", downOutage.ID)
 
 			resp, err := http.Get(serverURL + "/api/status/Prow/Tide")
@@ -657,7 +1636,7 @@ func testSubComponentStatus(serverURL string) func(*testing.T) {
 			require.NoError(t, err)
 			updateReq.Header.Set("Content-Type", "application/json")
 
-			client := &http.Client{}
+			client := authenticatedClient()
 			updateResp, err := client.Do(updateReq)
 			require.NoError(t, err)
 			defer updateResp.Body.Close()
@@ -783,6 +1762,20 @@ func testComponentStatus(serverURL string) func(*testing.T) {
 }
 
 func createOutageWithSeverity(t *testing.T, serverURL, componentName, subComponentName, severity string) types.Outage {
+	return createOutageWithClient(t, authenticatedClient(), serverURL, componentName, subComponentName, severity)
+}
+
+// createSeparateOutage creates an outage even if the sub-component already has an active one
+func createSeparateOutage(t *testing.T, serverURL, componentName, subComponentName, severity string) types.Outage {
+	return postOutage(t, authenticatedClient(), serverURL+"/api/components/"+componentName+"/"+subComponentName+"/outages?on_duplicate=create", severity)
+}
+
+// createOutageWithClient creates an outage using the provided (authenticated) client
+func createOutageWithClient(t *testing.T, client *http.Client, serverURL, componentName, subComponentName, severity string) types.Outage {
+	return postOutage(t, client, serverURL+"/api/components/"+componentName+"/"+subComponentName+"/outages", severity)
+}
+
+func postOutage(t *testing.T, client *http.Client, outagesURL, severity string) types.Outage {
 	outagePayload := map[string]interface{}{
 		"severity":        severity,
 		"start_time":      time.Now().UTC().Format(time.RFC3339),
@@ -794,12 +1787,10 @@ func createOutageWithSeverity(t *testing.T, serverURL, componentName, subCompone
 	payloadBytes, err := json.Marshal(outagePayload)
 	require.NoError(t, err)
 
-	req, err := http.NewRequest("POST", serverURL+"/api/components/"+componentName+"/"+subComponentName+"/outages",
-		bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequest("POST", outagesURL, bytes.NewBuffer(payloadBytes))
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	require.NoError(t, err)
 	defer resp.Body.Close()
@@ -812,6 +1803,7 @@ func createOutageWithSeverity(t *testing.T, serverURL, componentName, subCompone
 
 	return outage
 }
+
 func testAllComponentsStatus(serverURL string) func(*testing.T) {
 	return func(t *testing.T) {
 		t.Run("GET status for all components returns all components with their status", func(t *testing.T) {